@@ -0,0 +1,52 @@
+package goleptjson
+
+import (
+	"fmt"
+	"math"
+)
+
+// LeptCoerceBool interpret v as boolean like javascript truthiness:
+//
+//	true          -> true
+//	false         -> false
+//	null          -> false
+//	0, NaN        -> false
+//	other number  -> true
+//	""            -> false
+//	other string  -> true
+//
+// array and object get an error
+func LeptCoerceBool(v *LeptValue) (bool, error) {
+	if v == nil {
+		panic("LeptCoerceBool v is nil")
+	}
+	switch v.typ {
+	case LeptTrue:
+		return true, nil
+	case LeptFalse, LeptNull:
+		return false, nil
+	case LeptNumber:
+		return v.n != 0 && !math.IsNaN(v.n), nil
+	case LeptString:
+		return v.s != "", nil
+	default:
+		return false, fmt.Errorf("v LeptValue %v can not coerce to boolean", v.typ)
+	}
+}
+
+// LeptCoerceString return the string form of v, the content of string,
+// number by the stringify number format, "true" "false" for boolean, "" for null,
+// and the compact json for array and object
+func LeptCoerceString(v *LeptValue) string {
+	if v == nil {
+		panic("LeptCoerceString v is nil")
+	}
+	switch v.typ {
+	case LeptNull:
+		return ""
+	case LeptString:
+		return v.s
+	default:
+		return leptStringifyValue(v, nil)
+	}
+}
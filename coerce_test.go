@@ -0,0 +1,76 @@
+package goleptjson
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLeptCoerceBool(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"null", false},
+		{"0", false},
+		{"-0", false},
+		{"0.0", false},
+		{"1", true},
+		{"-0.5", true},
+		{"1e-300", true},
+		{"\"\"", false},
+		{"\"a\"", true},
+		{"\"false\"", true},
+		{"\"0\"", true},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		b, err := LeptCoerceBool(v)
+		if err != nil {
+			t.Errorf("LeptCoerceBool %v expect no err: %v", c.input, err)
+		}
+		expectEQBool(t, c.expect, b)
+	}
+	v := NewLeptValue()
+	LeptSetNumber(v, math.NaN())
+	b, err := LeptCoerceBool(v)
+	if err != nil {
+		t.Errorf("LeptCoerceBool NaN expect no err: %v", err)
+	}
+	expectEQBool(t, false, b)
+	invalid := []string{"[]", "[1]", "{}", "{\"a\":true}"}
+	for _, input := range invalid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+		if _, err := LeptCoerceBool(v); err == nil {
+			t.Errorf("LeptCoerceBool %v should have err", input)
+		}
+	}
+}
+
+func TestLeptCoerceString(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"null", ""},
+		{"true", "true"},
+		{"false", "false"},
+		{"0", "0"},
+		{"-1.5", "-1.5"},
+		{"1e20", "1e+20"},
+		{"\"\"", ""},
+		{"\"a \\\"b\\\"\\n\"", "a \"b\"\n"},
+		{"[]", "[]"},
+		{"[1, \"a\", null]", "[1,\"a\",null]"},
+		{"{}", "{}"},
+		{"{\"a\" : {\"b\" : [true]}}", "{\"a\":{\"b\":[true]}}"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQString(t, c.expect, LeptCoerceString(v))
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -149,3 +151,49 @@ func BenchmarkTwitterJSON(b *testing.B) {
 		// fmt.Println(event)
 	}
 }
+
+// benchmarkParse parse input b.N times and report the throughput
+func benchmarkParse(b *testing.B, input string) {
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := NewLeptValue()
+		if event := LeptParse(v, input); event != LeptParseOK {
+			b.Errorf("benchmark parse err : %v", event)
+		}
+	}
+}
+
+// benchmarkRecord return an object like an item of a REST response
+func benchmarkRecord(i int) string {
+	return fmt.Sprintf("{\"id\":%d,\"name\":\"user %d\",\"email\":\"user%d@example.com\","+
+		"\"score\":%d.%02d,\"active\":%v,\"tags\":[\"a\",\"b\\u00e9\",\"c\"],\"parent\":null,"+
+		"\"location\":{\"lat\":%d.125,\"lng\":-%d.5e-1}}", i, i, i, i%100, i%97, i%2 == 0, i%90, i%180)
+}
+
+func BenchmarkParseNumber(b *testing.B) {
+	benchmarkParse(b, "-12345.678901234e-12")
+}
+func BenchmarkParseString(b *testing.B) {
+	input := "\"" + strings.Repeat("Hello, World! \\\"quoted\\\" \\u4e2d\\u6587 \\n", 100) + "\""
+	benchmarkParse(b, input)
+}
+func BenchmarkParseArray(b *testing.B) {
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = strconv.FormatFloat(float64(i)*1.5, 'g', -1, 64)
+	}
+	benchmarkParse(b, "["+strings.Join(items, ", ")+"]")
+}
+func BenchmarkParseObject(b *testing.B) {
+	benchmarkParse(b, benchmarkRecord(1))
+}
+func BenchmarkParseLarge(b *testing.B) {
+	// about 4 MB
+	items := make([]string, 20000)
+	for i := range items {
+		items[i] = benchmarkRecord(i)
+	}
+	benchmarkParse(b, "{\"data\":[\n"+strings.Join(items, ",\n")+"\n],\"total\":20000}")
+}
@@ -0,0 +1,272 @@
+package goleptjson
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// LeptValueTooLargeError the streamed value exceeds StreamMaxValueBytes
+type LeptValueTooLargeError struct {
+	// Index the index of the value in stream
+	Index int
+	// Limit the StreamMaxValueBytes of decoder
+	Limit int
+}
+
+func (e *LeptValueTooLargeError) Error() string {
+	return fmt.Sprintf("goleptjson: stream value %v exceeds %v bytes", e.Index, e.Limit)
+}
+
+// LeptDecoder read and parse the whitespace separated json values from a stream one by one,
+// like NDJSON or concatenated json documents
+type LeptDecoder struct {
+	r *bufio.Reader
+	// StreamMaxValueBytes limit the bytes of a single streamed value, 0 means unlimited
+	StreamMaxValueBytes int
+
+	buf    bytes.Buffer
+	index  int
+	offset int // bytes read from r
+	start  int // offset of the current value
+	err    error
+}
+
+// NewLeptDecoder return a LeptDecoder read from r
+func NewLeptDecoder(r io.Reader) *LeptDecoder {
+	return &LeptDecoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Decode parse the next value of stream into v, return io.EOF if there is no more value.
+// a failed read is wrapped with %w, invalid json is reported as *LeptError with the stream offset.
+// the decoder can not go on after an error
+func (d *LeptDecoder) Decode(v *LeptValue) error {
+	if v == nil {
+		panic("LeptDecoder Decode v is nil")
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if err := d.readValue(); err != nil {
+		d.err = err
+		return err
+	}
+	c := NewLeptContext(d.buf.String())
+	if event := leptParse(c, v); event != LeptParseOK {
+		d.err = &LeptError{Event: event, Offset: d.start + leptOffset(c)}
+		return d.err
+	}
+	d.index++
+	return nil
+}
+
+// LeptParseReader read all of r and parse it into v,
+// a failed read is wrapped with %w, invalid json is reported as *LeptError
+func LeptParseReader(v *LeptValue, r io.Reader) error {
+	if v == nil {
+		panic("LeptParseReader v is nil")
+	}
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("goleptjson: read failed: %w", err)
+	}
+	c := NewLeptContext(string(buf))
+	if event := leptParse(c, v); event != LeptParseOK {
+		return &LeptError{Event: event, Offset: leptOffset(c)}
+	}
+	return nil
+}
+
+// LeptParseStream parse the consecutive json values of the whole input like NDJSON,
+// "1 2 3" gives three numbers. string, array and object end with their own delimiter,
+// so they need no separator and "[1][2]" gives two arrays, while null true false and number
+// must be followed by whitespace or the end, so "1-2" and "nulltrue" are invalid.
+// on failure the values before the invalid one are returned with the event,
+// offset is where parsing stopped, len(json) on success
+func LeptParseStream(json string) (values []*LeptValue, event LeptEvent, offset int) {
+	c := NewLeptContext(json)
+	values = make([]*LeptValue, 0)
+	for {
+		LeptParseWhitespace(c)
+		if c.eof() {
+			return values, LeptParseOK, leptOffset(c)
+		}
+		v := NewLeptValue()
+		if event := LeptParseValue(c, v); event != LeptParseOK {
+			return values, event, leptOffset(c)
+		}
+		switch v.typ {
+		case LeptString, LeptArray, LeptObject:
+		default:
+			if ch := c.peek(); !c.eof() && ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r' {
+				return values, LeptParseRootNotSingular, leptOffset(c)
+			}
+		}
+		values = append(values, v)
+	}
+}
+
+// More report whether there is another value in stream
+func (d *LeptDecoder) More() bool {
+	if d.err != nil {
+		return false
+	}
+	if _, err := d.skipWhitespace(); err != nil {
+		if err != io.EOF {
+			d.err = err
+		}
+		return false
+	}
+	d.unreadByte()
+	return true
+}
+
+// readByte read a byte from r, io.EOF is returned as is, other error is wrapped
+func (d *LeptDecoder) readByte() (byte, error) {
+	ch, err := d.r.ReadByte()
+	if err == io.EOF {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("goleptjson: read failed at offset %v: %w", d.offset, err)
+	}
+	d.offset++
+	return ch, nil
+}
+
+func (d *LeptDecoder) unreadByte() {
+	d.r.UnreadByte()
+	d.offset--
+}
+
+func (d *LeptDecoder) skipWhitespace() (byte, error) {
+	for {
+		ch, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+		if ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r' {
+			return ch, nil
+		}
+	}
+}
+
+// readValue read the bytes of next value into buf,
+// it only finds the end of value, LeptParse will check the grammar
+func (d *LeptDecoder) readValue() error {
+	d.buf.Reset()
+	ch, err := d.skipWhitespace()
+	if err != nil {
+		return err
+	}
+	d.start = d.offset - 1
+	if err := d.writeByte(ch); err != nil {
+		return err
+	}
+	switch ch {
+	case '"':
+		return d.readString()
+	case '[', '{':
+		depth := 1
+		for depth > 0 {
+			ch, err := d.readByte()
+			if err == io.EOF {
+				// leave the truncated value to LeptParse
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := d.writeByte(ch); err != nil {
+				return err
+			}
+			switch ch {
+			case '"':
+				if err := d.readString(); err != nil {
+					return err
+				}
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+			}
+		}
+		return nil
+	default:
+		// null true false and number end with whitespace or other token
+		for {
+			ch, err := d.readByte()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			switch ch {
+			case ' ', '\t', '\n', '\r', '[', ']', '{', '}', ',', ':', '"':
+				d.unreadByte()
+				return nil
+			}
+			if err := d.writeByte(ch); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readString read until the quotation mark which is not escaped
+func (d *LeptDecoder) readString() error {
+	escape := false
+	for {
+		ch, err := d.readByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := d.writeByte(ch); err != nil {
+			return err
+		}
+		if escape {
+			escape = false
+		} else if ch == '\\' {
+			escape = true
+		} else if ch == '"' {
+			return nil
+		}
+	}
+}
+
+func (d *LeptDecoder) writeByte(ch byte) error {
+	if d.StreamMaxValueBytes > 0 && d.buf.Len() >= d.StreamMaxValueBytes {
+		return &LeptValueTooLargeError{Index: d.index, Limit: d.StreamMaxValueBytes}
+	}
+	return d.buf.WriteByte(ch)
+}
+
+// LeptJSONLinesWriter write the values as JSON Lines, one compact value per line,
+// the newlines in strings are escaped by LeptStringify so that a record never spans lines
+type LeptJSONLinesWriter struct {
+	w io.Writer
+}
+
+// NewLeptJSONLinesWriter return a LeptJSONLinesWriter write to w
+func NewLeptJSONLinesWriter(w io.Writer) *LeptJSONLinesWriter {
+	return &LeptJSONLinesWriter{w: w}
+}
+
+// Write write v and a newline, a failed write is wrapped with %w
+func (jw *LeptJSONLinesWriter) Write(v *LeptValue) error {
+	if v == nil {
+		panic("LeptJSONLinesWriter Write v is nil")
+	}
+	if _, err := io.WriteString(jw.w, LeptStringify(v)+"\n"); err != nil {
+		return fmt.Errorf("goleptjson: write failed: %w", err)
+	}
+	return nil
+}
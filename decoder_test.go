@@ -0,0 +1,251 @@
+package goleptjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLeptDecoder(t *testing.T) {
+	input := "null true\nfalse 123 \"a b\\\"c\"\n[1, [2]]{\"a\":{\"b\":\"}\"}}\r\n-1.5e3"
+	expects := []string{"null", "true", "false", "123", "\"a b\\\"c\"", "[1,[2]]", "{\"a\":{\"b\":\"}\"}}", "-1500"}
+	d := NewLeptDecoder(strings.NewReader(input))
+	for _, expect := range expects {
+		expectEQBool(t, true, d.More())
+		v := NewLeptValue()
+		if err := d.Decode(v); err != nil {
+			t.Errorf("Decode expect no err: %v", err)
+			return
+		}
+		expectEQString(t, expect, LeptStringify(v))
+	}
+	expectEQBool(t, false, d.More())
+	if err := d.Decode(NewLeptValue()); err != io.EOF {
+		t.Errorf("Decode expect io.EOF, actual: %v", err)
+	}
+}
+func TestLeptDecoderReuse(t *testing.T) {
+	// the old value is dropped when v is reused
+	d := NewLeptDecoder(strings.NewReader("[1,2] [3] {\"a\":1} {\"b\":2} 4"))
+	v := NewLeptValue()
+	for _, expect := range []string{"[1,2]", "[3]", "{\"a\":1}", "{\"b\":2}", "4"} {
+		if err := d.Decode(v); err != nil {
+			t.Errorf("Decode expect no err: %v", err)
+			return
+		}
+		expectEQString(t, expect, LeptStringify(v))
+	}
+}
+func TestLeptDecoderInvalid(t *testing.T) {
+	d := NewLeptDecoder(strings.NewReader("1 [1 2] 3"))
+	v := NewLeptValue()
+	if err := d.Decode(v); err != nil {
+		t.Errorf("Decode expect no err: %v", err)
+	}
+	if err := d.Decode(v); err == nil {
+		t.Errorf("Decode expect err of [1 2]")
+	}
+	expectEQBool(t, false, d.More())
+}
+func TestLeptParseStream(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect []string
+	}{
+		{"1 2 3", []string{"1", "2", "3"}},
+		{"{\"id\":1,\"tags\":[\"a\"]}\n{\"id\":2,\"tags\":[]}\n{\"id\":3,\"note\":\"x\\ny\"}\n", []string{"{\"id\":1,\"tags\":[\"a\"]}", "{\"id\":2,\"tags\":[]}", "{\"id\":3,\"note\":\"x\\ny\"}"}},
+		{"\r\n null\ttrue\r\nfalse \"s\" ", []string{"null", "true", "false", "\"s\""}},
+		// string, array and object need no separator
+		{"[1][2]{}\"a\"\"b\"", []string{"[1]", "[2]", "{}", "\"a\"", "\"b\""}},
+		{"{}\"a\"[]1", []string{"{}", "\"a\"", "[]", "1"}},
+		{"[1]\"a\"{} 2\n[]null", []string{"[1]", "\"a\"", "{}", "2", "[]", "null"}},
+		{"", []string{}},
+		{" \n ", []string{}},
+	}
+	for _, c := range valid {
+		values, event, offset := LeptParseStream(c.input)
+		expectEQLeptEvent(t, LeptParseOK, event)
+		expectEQInt(t, len(c.input), offset)
+		expectEQInt(t, len(c.expect), len(values))
+		for i := 0; i < len(c.expect) && i < len(values); i++ {
+			expectEQString(t, c.expect[i], LeptStringify(values[i]))
+		}
+	}
+	// the values before the invalid one are kept
+	invalid := []struct {
+		input  string
+		event  LeptEvent
+		expect int
+		offset int
+	}{
+		{"1 [1 2] 3", LeptParseMissCommaOrSouareBracket, 1, 5},
+		{"{\"a\":1}\n{\"a\":\n", LeptParseExpectValue, 1, 14},
+		{"1 2 x", LeptParseInvalidValue, 2, 4},
+		{"1,2", LeptParseRootNotSingular, 0, 1},
+		// a scalar needs whitespace or the end after it
+		{"1-2", LeptParseRootNotSingular, 0, 1},
+		{"nulltrue", LeptParseRootNotSingular, 0, 4},
+		{"true false1", LeptParseRootNotSingular, 1, 10},
+		{"1[2]", LeptParseRootNotSingular, 0, 1},
+		{"[1] -1\"a\"", LeptParseRootNotSingular, 1, 6},
+	}
+	for _, c := range invalid {
+		values, event, offset := LeptParseStream(c.input)
+		expectEQLeptEvent(t, c.event, event)
+		expectEQInt(t, c.expect, len(values))
+		expectEQInt(t, c.offset, offset)
+	}
+	// LeptParse stays strict
+	expectEQLeptEvent(t, LeptParseRootNotSingular, LeptParse(NewLeptValue(), "1 2 3"))
+}
+func TestLeptDecoderStreamMaxValueBytes(t *testing.T) {
+	input := "[1,2] {\"a\":1} \"" + strings.Repeat("x", 100) + "\" [3]"
+	{
+		d := NewLeptDecoder(strings.NewReader(input))
+		count := 0
+		for d.More() {
+			if err := d.Decode(NewLeptValue()); err != nil {
+				t.Errorf("Decode unlimited expect no err: %v", err)
+				break
+			}
+			count++
+		}
+		expectEQInt(t, 4, count)
+	}
+	{
+		d := NewLeptDecoder(strings.NewReader(input))
+		d.StreamMaxValueBytes = 16
+		v := NewLeptValue()
+		for i := 0; i < 2; i++ {
+			if err := d.Decode(v); err != nil {
+				t.Errorf("Decode expect no err: %v", err)
+			}
+		}
+		err := d.Decode(v)
+		var tooLarge *LeptValueTooLargeError
+		if !errors.As(err, &tooLarge) {
+			t.Errorf("Decode expect LeptValueTooLargeError, actual: %v", err)
+		} else {
+			expectEQInt(t, 2, tooLarge.Index)
+			expectEQInt(t, 16, tooLarge.Limit)
+		}
+	}
+}
+
+// brokenReader return data and then err
+type brokenReader struct {
+	data string
+	err  error
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestLeptDecoderReadFailed(t *testing.T) {
+	errBroken := errors.New("broken pipe")
+	d := NewLeptDecoder(&brokenReader{data: "[1,2] [3", err: errBroken})
+	v := NewLeptValue()
+	if err := d.Decode(v); err != nil {
+		t.Errorf("Decode expect no err: %v", err)
+	}
+	err := d.Decode(v)
+	if !errors.Is(err, errBroken) {
+		t.Errorf("Decode expect wrapped read err, actual: %v", err)
+	}
+	var lerr *LeptError
+	if errors.As(err, &lerr) {
+		t.Errorf("Decode read err should not be a LeptError: %v", err)
+	}
+	if err := d.Decode(v); !errors.Is(err, errBroken) {
+		t.Errorf("Decode expect the same err after failure, actual: %v", err)
+	}
+}
+func TestLeptDecoderInvalidJSON(t *testing.T) {
+	d := NewLeptDecoder(strings.NewReader("[1]  {\"a\" 1}"))
+	v := NewLeptValue()
+	if err := d.Decode(v); err != nil {
+		t.Errorf("Decode expect no err: %v", err)
+	}
+	err := d.Decode(v)
+	var lerr *LeptError
+	if !errors.As(err, &lerr) {
+		t.Errorf("Decode expect LeptError, actual: %v", err)
+	} else {
+		expectEQLeptEvent(t, LeptParseMissColon, lerr.Event)
+		expectEQInt(t, 10, lerr.Offset)
+	}
+}
+func TestLeptParseReader(t *testing.T) {
+	v := NewLeptValue()
+	if err := LeptParseReader(v, strings.NewReader(" [1, \"a\"] ")); err != nil {
+		t.Errorf("LeptParseReader expect no err: %v", err)
+	}
+	expectEQString(t, "[1,\"a\"]", LeptStringify(v))
+
+	err := LeptParseReader(v, strings.NewReader("[1, ?]"))
+	var lerr *LeptError
+	if !errors.As(err, &lerr) {
+		t.Errorf("LeptParseReader expect LeptError, actual: %v", err)
+	} else {
+		expectEQLeptEvent(t, LeptParseInvalidValue, lerr.Event)
+	}
+
+	errBroken := errors.New("broken pipe")
+	err = LeptParseReader(v, &brokenReader{data: "[1,", err: errBroken})
+	if !errors.Is(err, errBroken) || errors.As(err, &lerr) {
+		t.Errorf("LeptParseReader expect wrapped read err, actual: %v", err)
+	}
+}
+
+func TestLeptJSONLinesWriter(t *testing.T) {
+	inputs := []string{"{\"msg\":\"line1\\nline2\\r\\n\",\"n\":1}", "[1, \"a\\u2028b\", null]", "\"tab\\there\"", "3.5", "{}"}
+	var buf strings.Builder
+	jw := NewLeptJSONLinesWriter(&buf)
+	records := make([]*LeptValue, len(inputs))
+	for i, input := range inputs {
+		records[i] = NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(records[i], input))
+		if err := jw.Write(records[i]); err != nil {
+			t.Errorf("LeptJSONLinesWriter Write expect no err: %v", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	expectEQInt(t, len(inputs), len(lines))
+
+	d := NewLeptDecoder(strings.NewReader(buf.String()))
+	for i := range inputs {
+		v := NewLeptValue()
+		if err := d.Decode(v); err != nil {
+			t.Errorf("Decode expect no err: %v", err)
+			return
+		}
+		expectEQBool(t, true, LeptIsEqual(records[i], v))
+	}
+	if err := d.Decode(NewLeptValue()); err != io.EOF {
+		t.Errorf("Decode expect io.EOF, actual: %v", err)
+	}
+}
+
+// brokenWriter always fail with err
+type brokenWriter struct {
+	err error
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestLeptJSONLinesWriterWriteFailed(t *testing.T) {
+	errBroken := errors.New("broken pipe")
+	jw := NewLeptJSONLinesWriter(&brokenWriter{err: errBroken})
+	if err := jw.Write(NewLeptValue()); !errors.Is(err, errBroken) {
+		t.Errorf("LeptJSONLinesWriter Write expect wrapped err, actual: %v", err)
+	}
+}
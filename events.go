@@ -0,0 +1,24 @@
+package goleptjson
+
+// LeptEventHandler receive the values of LeptParseEvents in document order, no tree is built,
+// a key is followed by its value, the elements and members come between the start and the end
+type LeptEventHandler interface {
+	OnNull()
+	OnBool(b bool)
+	OnNumber(n float64)
+	OnString(s string)
+	OnArrayStart()
+	OnArrayEnd()
+	OnObjectStart()
+	OnObjectEnd()
+	OnKey(key string)
+}
+
+// LeptParseEvents parse json like LeptParse with the default options and report every value to handler
+// instead of building the tree, on failure the events before the invalid token have been reported
+func LeptParseEvents(json string, handler LeptEventHandler) LeptEvent {
+	if handler == nil {
+		panic("LeptParseEvents handler is nil")
+	}
+	return leptValidate(NewLeptContext(json), handler)
+}
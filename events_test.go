@@ -0,0 +1,55 @@
+package goleptjson
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// leptRecorder record the events as tokens
+type leptRecorder struct {
+	tokens []string
+	keys   []string
+}
+
+func (r *leptRecorder) OnNull()            { r.tokens = append(r.tokens, "null") }
+func (r *leptRecorder) OnBool(b bool)      { r.tokens = append(r.tokens, strconv.FormatBool(b)) }
+func (r *leptRecorder) OnNumber(n float64) { r.tokens = append(r.tokens, leptStringifyNumber(n)) }
+func (r *leptRecorder) OnString(s string)  { r.tokens = append(r.tokens, leptStringifyString(s)) }
+func (r *leptRecorder) OnArrayStart()      { r.tokens = append(r.tokens, "[") }
+func (r *leptRecorder) OnArrayEnd()        { r.tokens = append(r.tokens, "]") }
+func (r *leptRecorder) OnObjectStart()     { r.tokens = append(r.tokens, "{") }
+func (r *leptRecorder) OnObjectEnd()       { r.tokens = append(r.tokens, "}") }
+func (r *leptRecorder) OnKey(key string) {
+	r.tokens = append(r.tokens, leptStringifyString(key)+":")
+	r.keys = append(r.keys, key)
+}
+
+func TestLeptParseEvents(t *testing.T) {
+	input := "{\"name\":\"a\",\"list\":[1,{\"x\":null,\"y\":[true,false]}],\"meta\":{\"\":{},\"k\\u00e9\":[]}}"
+	r := &leptRecorder{}
+	expectEQLeptEvent(t, LeptParseOK, LeptParseEvents(input, r))
+	expectEQString(t, "name list x y meta  k\u00e9", strings.Join(r.keys, " "))
+	expectEQString(t, "{ \"name\": \"a\" \"list\": [ 1 { \"x\": null \"y\": [ true false ] } ] \"meta\": { \"\": { } \"k\u00e9\": [ ] } }",
+		strings.Join(r.tokens, " "))
+
+	inputs := []string{
+		"null", " true ", "-1.5e3", "\"a\\n\"", "[]", "{}", "[[],[[]],{\"a\":{}}]", "{\"a\":1,\"a\":2}",
+		"", "[1,]", "{\"a\":1,}", "{\"a\" 1}", "[\"\\x\"]", "\"\\uD800\"", "1e309", "[1]x", "[nul]",
+	}
+	for _, input := range inputs {
+		ce := NewLeptContext(input)
+		expect := leptParse(ce, NewLeptValue())
+		ca := NewLeptContext(input)
+		expectEQLeptEvent(t, expect, leptValidate(ca, &leptRecorder{}))
+		expectEQLeptEvent(t, expect, LeptParseEvents(input, &leptRecorder{}))
+		if ce.pos != ca.pos {
+			t.Errorf("LeptParseEvents %q expect offset %d, actual: %d", input, ce.pos, ca.pos)
+		}
+	}
+	// the events before the failure are reported
+	r = &leptRecorder{}
+	expectEQLeptEvent(t, LeptParseInvalidValue, LeptParseEvents("{\"a\":[1,\"b\",x]}", r))
+	expectEQString(t, "{ \"a\": [ 1 \"b\"", strings.Join(r.tokens, " "))
+	expectPanic(t, "LeptParseEvents", func() { LeptParseEvents("1", nil) })
+}
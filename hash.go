@@ -0,0 +1,63 @@
+package goleptjson
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// LeptHash return the FNV-1a hash of the canonical form of v,
+// the members of object are hashed in key order so the values equal by LeptIsEqual hash equally
+func LeptHash(v *LeptValue) uint64 {
+	if v == nil {
+		panic("LeptHash v is nil")
+	}
+	h := fnv.New64a()
+	leptHashValue(h, v)
+	return h.Sum64()
+}
+
+func leptHashValue(h hash.Hash64, v *LeptValue) {
+	var buf [8]byte
+	h.Write([]byte{byte(v.typ)})
+	switch v.typ {
+	case LeptNumber:
+		n := v.n
+		if n == 0 {
+			// -0 == 0
+			n = 0
+		}
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(n))
+		h.Write(buf[:])
+	case LeptString:
+		leptHashString(h, v.s)
+	case LeptArray:
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(v.a)))
+		h.Write(buf[:])
+		for _, e := range v.a {
+			leptHashValue(h, e)
+		}
+	case LeptObject:
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(v.o)))
+		h.Write(buf[:])
+		members := make([]*LeptMember, len(v.o))
+		copy(members, v.o)
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].key < members[j].key
+		})
+		for _, member := range members {
+			leptHashString(h, member.key)
+			leptHashValue(h, member.value)
+		}
+	}
+}
+
+// leptHashString write the length before the content, so that ["ab","c"] and ["a","bc"] differ
+func leptHashString(h hash.Hash64, s string) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(s)))
+	h.Write(buf[:])
+	h.Write([]byte(s))
+}
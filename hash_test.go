@@ -0,0 +1,53 @@
+package goleptjson
+
+import (
+	"testing"
+)
+
+func TestLeptHash(t *testing.T) {
+	equal := []struct {
+		lhs string
+		rhs string
+	}{
+		{"null", "null"},
+		{"true", "true"},
+		{"0", "-0"},
+		{"1.5", "15e-1"},
+		{"\"a\"", "\"\\u0061\""},
+		{"[1, [2, 3]]", "[1,[2,3]]"},
+		{"{\"a\":1,\"b\":2}", "{\"b\":2,\"a\":1}"},
+		{"{\"x\":{\"a\":[1,{\"c\":null,\"d\":true}],\"b\":\"s\"}}", "{\"x\":{\"b\":\"s\",\"a\":[1,{\"d\":true,\"c\":null}]}}"},
+	}
+	for _, c := range equal {
+		lhs, rhs := NewLeptValue(), NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(lhs, c.lhs))
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(rhs, c.rhs))
+		expectEQBool(t, true, LeptIsEqual(lhs, rhs))
+		if LeptHash(lhs) != LeptHash(rhs) {
+			t.Errorf("LeptHash %v and %v expect equal", c.lhs, c.rhs)
+		}
+	}
+	notEqual := []struct {
+		lhs string
+		rhs string
+	}{
+		{"null", "false"},
+		{"true", "false"},
+		{"0", "\"0\""},
+		{"1", "2"},
+		{"[]", "{}"},
+		{"[1, 2]", "[2, 1]"},
+		{"[\"ab\", \"c\"]", "[\"a\", \"bc\"]"},
+		{"{\"a\":1}", "{\"a\":2}"},
+		{"{\"a\":1}", "{\"b\":1}"},
+		{"{\"a\":[]}", "{\"a\":[[]]}"},
+	}
+	for _, c := range notEqual {
+		lhs, rhs := NewLeptValue(), NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(lhs, c.lhs))
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(rhs, c.rhs))
+		if LeptHash(lhs) == LeptHash(rhs) {
+			t.Errorf("LeptHash %v and %v expect different", c.lhs, c.rhs)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package goleptjson
+
+// leptFrame an array or object opened by the iterative parser and not closed yet
+type leptFrame struct {
+	v      *LeptValue
+	object bool
+	key    string // the key of the member being parsed, object only
+}
+
+// LeptParseIterative use to parse value like LeptParse with an explicit stack instead of recursion,
+// so that arbitrarily nested arrays and objects never exhaust the goroutine stack, MaxDepth does not apply
+func LeptParseIterative(v *LeptValue, json string) LeptEvent {
+	if v == nil {
+		panic("LeptParseIterative v is nil")
+	}
+	leptCheckMutable(v, "LeptParseIterative")
+	LeptFree(v)
+	c := NewLeptContext(json)
+	LeptParseWhitespace(c)
+	if ret := leptParseIterative(c, v); ret != LeptParseOK {
+		return ret
+	}
+	LeptParseWhitespace(c)
+	if !c.eof() {
+		return LeptParseRootNotSingular
+	}
+	return LeptParseOK
+}
+
+// leptParseIterative parse a value into root, c stops at the same offset as LeptParseValue on failure
+func leptParseIterative(c *LeptContext, root *LeptValue) LeptEvent {
+	var stack []leptFrame
+	v := root
+	for {
+		// v is the slot of the next value
+		switch c.peek() {
+		case '[':
+			c.pos++
+			LeptParseWhitespace(c)
+			if c.eof() {
+				return LeptParseMissCommaOrSouareBracket
+			}
+			if c.peek() != ']' {
+				stack = append(stack, leptFrame{v: v})
+				v = NewLeptValue()
+				continue
+			}
+			c.pos++
+			v.typ = LeptArray
+			v.a = make([]*LeptValue, 0)
+		case '{':
+			c.pos++
+			LeptParseWhitespace(c)
+			if c.eof() {
+				return LeptParseMissCommaOrCurlyBracket
+			}
+			if c.peek() != '}' {
+				key, ret := leptParseMemberKey(c)
+				if ret != LeptParseOK {
+					return ret
+				}
+				stack = append(stack, leptFrame{v: v, object: true, key: key})
+				v = NewLeptValue()
+				continue
+			}
+			c.pos++
+			v.typ = LeptObject
+			v.a = make([]*LeptValue, 0)
+			v.o = make([]*LeptMember, 0)
+		default:
+			if ret := LeptParseValue(c, v); ret != LeptParseOK {
+				return ret
+			}
+		}
+		// v is complete, add it to the innermost container and close the containers that end here
+		for {
+			if len(stack) == 0 {
+				return LeptParseOK
+			}
+			top := &stack[len(stack)-1]
+			if top.object {
+				top.v.o = append(top.v.o, &LeptMember{key: top.key, value: v})
+			} else {
+				top.v.a = append(top.v.a, v)
+			}
+			LeptParseWhitespace(c)
+			if c.peek() == ',' {
+				c.pos++
+				LeptParseWhitespace(c)
+				if top.object {
+					key, ret := leptParseMemberKey(c)
+					if ret != LeptParseOK {
+						return ret
+					}
+					top.key = key
+				}
+				v = NewLeptValue()
+				break
+			}
+			if !top.object && c.peek() == ']' {
+				top.v.typ = LeptArray
+			} else if top.object && c.peek() == '}' {
+				top.v.typ = LeptObject
+			} else if top.object {
+				return LeptParseMissCommaOrCurlyBracket
+			} else {
+				return LeptParseMissCommaOrSouareBracket
+			}
+			c.pos++
+			v = top.v
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// leptParseMemberKey parse the key and colon of a member, and the whitespace after them
+func leptParseMemberKey(c *LeptContext) (string, LeptEvent) {
+	if c.peek() != '"' {
+		return "", LeptParseMissKey
+	}
+	key, ret := LeptParseStringRaw(c)
+	if ret != LeptParseOK {
+		return "", ret
+	}
+	LeptParseWhitespace(c)
+	if c.peek() != ':' {
+		return "", LeptParseMissColon
+	}
+	c.pos++
+	LeptParseWhitespace(c)
+	return key, LeptParseOK
+}
@@ -0,0 +1,93 @@
+package goleptjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLeptParseIterative(t *testing.T) {
+	inputs := []string{
+		"null", " true ", "false", "-1.5e3", "\"a\\u00e9\\n\"",
+		"[]", "{}", "[ ]", "{ }", "[1,[],{},[[]],{\"a\":{}}]",
+		"{\"z\":1,\"a\":[true,false,null],\"m\":{\"x\":\"y\",\"\":[{}]}}",
+		" [ 1 , [ 2 , [ 3 ] ] , { \"k\" : [ ] } ] ",
+		// invalid, the event and offset agree with the recursive parser
+		"", " ", "[", "{", "[1", "[1,", "[1,]", "[1 2]", "[1}", "{\"a\":1]",
+		"{\"a\"", "{\"a\":", "{\"a\":1,", "{\"a\":1,}", "{1:1}", "{\"a\" 1}", "{\"a\\x\":1}",
+		"[nul]", "[[[1,tru]]]", "{\"a\":[{\"b\":?}]}", "[\"\\u\"]", "[1]x", "[1] [2]", "01",
+	}
+	for _, input := range inputs {
+		expect := NewLeptValue()
+		ce := NewLeptContext(input)
+		expectEvent := leptParse(ce, expect)
+		actual := NewLeptValue()
+		ca := NewLeptContext(input)
+		LeptParseWhitespace(ca)
+		actualEvent := leptParseIterative(ca, actual)
+		if actualEvent == LeptParseOK {
+			LeptParseWhitespace(ca)
+			if !ca.eof() {
+				actualEvent = LeptParseRootNotSingular
+			}
+		}
+		expectEQLeptEvent(t, expectEvent, actualEvent)
+		expectEQLeptEvent(t, expectEvent, LeptParseIterative(NewLeptValue(), input))
+		if ce.pos != ca.pos {
+			t.Errorf("LeptParseIterative %q expect offset %d, actual: %d", input, ce.pos, ca.pos)
+		}
+		if expectEvent == LeptParseOK {
+			expectEQBool(t, true, LeptIsEqual(expect, actual))
+			expectEQString(t, LeptStringify(expect), LeptStringify(actual))
+		}
+	}
+
+	// the old value is dropped when v is reused
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseIterative(v, "[1,2]"))
+	expectEQLeptEvent(t, LeptParseOK, LeptParseIterative(v, "[3]"))
+	expectEQString(t, "[3]", LeptStringify(v))
+	expectEQLeptEvent(t, LeptParseOK, LeptParseIterative(v, "{\"a\":[1]}"))
+	expectEQLeptEvent(t, LeptParseOK, LeptParseIterative(v, "{\"b\":2}"))
+	expectEQString(t, "{\"b\":2}", LeptStringify(v))
+
+	// far beyond MaxDepth
+	n := 1000000
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseIterative(v, strings.Repeat("[{\"a\":", n)+"0"+strings.Repeat("}]", n)))
+	for i := 0; i < n; i++ {
+		v = LeptGetObjectValue(LeptGetArrayElement(v, 0), 0)
+	}
+	expectEQFloat64(t, 0, LeptGetNumber(v))
+	expectEQLeptEvent(t, LeptParseMissCommaOrSouareBracket, LeptParseIterative(NewLeptValue(), strings.Repeat("[", n)))
+	expectPanic(t, "LeptParseIterative", func() { LeptParseIterative(nil, "[]") })
+}
+
+func deepJSON(n int) string {
+	return strings.Repeat("[{\"a\":", n) + "0" + strings.Repeat("}]", n)
+}
+func BenchmarkParseDeep(b *testing.B) {
+	input := deepJSON(100000)
+	opts := NewLeptParseOptions()
+	opts.MaxDepth = 0
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := NewLeptValue()
+		if event := LeptParseWithOptions(v, input, opts); event != LeptParseOK {
+			b.Errorf("benchmark parse err : %v", event)
+		}
+	}
+}
+func BenchmarkParseDeepIterative(b *testing.B) {
+	input := deepJSON(100000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := NewLeptValue()
+		if event := LeptParseIterative(v, input); event != LeptParseOK {
+			b.Errorf("benchmark parse err : %v", event)
+		}
+	}
+}
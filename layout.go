@@ -0,0 +1,165 @@
+package goleptjson
+
+import (
+	"bytes"
+)
+
+// LeptLayout record the whitespace and the raw text of a parsed document in a structure parallel to the values,
+// so that LeptStringifyWithLayout reproduce the input byte for byte when it is unchanged,
+// the changed values are written compact while the rest keep their layout
+type LeptLayout struct {
+	before string // whitespace and comments before the root
+	after  string // whitespace and comments after the root
+	nodes  map[*LeptValue]*leptNodeLayout
+}
+
+type leptNodeLayout struct {
+	raw  string   // raw text of number and string
+	ws   []string // whitespace tokens inside array and object in order
+	keys []string // raw text of object keys
+}
+
+func (l *LeptLayout) node(v *LeptValue) *leptNodeLayout {
+	node := l.nodes[v]
+	if node == nil {
+		node = &leptNodeLayout{}
+		l.nodes[v] = node
+	}
+	return node
+}
+
+// LeptParseWithLayout use to parse value with the options, and record the layout of json
+func LeptParseWithLayout(v *LeptValue, json string, opts *LeptParseOptions) (LeptEvent, *LeptLayout) {
+	if v == nil {
+		panic("LeptParseWithLayout v is nil")
+	}
+	c := NewLeptContext(json)
+	if opts != nil {
+		c.opts = opts
+	}
+	c.layout = &LeptLayout{nodes: make(map[*LeptValue]*leptNodeLayout)}
+	if event := leptParse(c, v); event != LeptParseOK {
+		return event, nil
+	}
+	return LeptParseOK, c.layout
+}
+
+// leptLayoutWhitespace parse the whitespace and append it to tokens if the layout is recorded
+func leptLayoutWhitespace(c *LeptContext, tokens []string) []string {
+	before := c.pos
+	LeptParseWhitespace(c)
+	if c.layout != nil {
+		tokens = append(tokens, c.json[before:c.pos])
+	}
+	return tokens
+}
+
+// leptLayoutContainer record the whitespace tokens and the raw keys of a parsed container
+func leptLayoutContainer(c *LeptContext, v *LeptValue, tokens, keys []string) {
+	if c.layout != nil {
+		node := c.layout.node(v)
+		node.ws = tokens
+		node.keys = keys
+	}
+}
+
+// LeptStringifyWithLayout stringify v with the layout recorded by LeptParseWithLayout,
+// nil layout is the same as LeptStringify
+func LeptStringifyWithLayout(v *LeptValue, l *LeptLayout) string {
+	if v == nil {
+		panic("LeptStringifyWithLayout v is nil")
+	}
+	if l == nil {
+		return LeptStringify(v)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(l.before)
+	leptStringifyLayout(&buf, v, l)
+	buf.WriteString(l.after)
+	return buf.String()
+}
+
+func leptStringifyLayout(buf *bytes.Buffer, v *LeptValue, l *LeptLayout) {
+	node := l.nodes[v]
+	switch v.typ {
+	case LeptArray:
+		// [ ws0 e0 ws1 , ws2 e1 ws3 ]
+		if node == nil || !leptLayoutMatches(node, len(v.a), 2) {
+			node = nil
+		}
+		buf.WriteByte('[')
+		if node != nil && len(v.a) == 0 {
+			buf.WriteString(node.ws[0])
+		}
+		for i, e := range v.a {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if node != nil {
+				buf.WriteString(node.ws[2*i])
+			}
+			leptStringifyLayout(buf, e, l)
+			if node != nil {
+				buf.WriteString(node.ws[2*i+1])
+			}
+		}
+		buf.WriteByte(']')
+	case LeptObject:
+		// { ws0 k0 ws1 : ws2 v0 ws3 , ws4 k1 ws5 : ws6 v1 ws7 }
+		if node == nil || !leptLayoutMatches(node, len(v.o), 4) || len(node.keys) != len(v.o) {
+			node = nil
+		}
+		buf.WriteByte('{')
+		if node != nil && len(v.o) == 0 {
+			buf.WriteString(node.ws[0])
+		}
+		for i, member := range v.o {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if node == nil {
+				buf.WriteString(leptStringifyString(member.key))
+				buf.WriteByte(':')
+				leptStringifyLayout(buf, member.value, l)
+				continue
+			}
+			buf.WriteString(node.ws[4*i])
+			if key, ok := leptRawString(node.keys[i]); ok && key == member.key {
+				buf.WriteString(node.keys[i])
+			} else {
+				buf.WriteString(leptStringifyString(member.key))
+			}
+			buf.WriteString(node.ws[4*i+1])
+			buf.WriteByte(':')
+			buf.WriteString(node.ws[4*i+2])
+			leptStringifyLayout(buf, member.value, l)
+			buf.WriteString(node.ws[4*i+3])
+		}
+		buf.WriteByte('}')
+	default:
+		// the raw text is written only if it still denotes the value
+		if node != nil && node.raw != "" {
+			raw := NewLeptValue()
+			if LeptParse(raw, node.raw) == LeptParseOK && raw.typ == v.typ && LeptIsEqual(raw, v) {
+				buf.WriteString(node.raw)
+				return
+			}
+		}
+		buf.WriteString(leptStringifyValue(v, nil))
+	}
+}
+
+// leptLayoutMatches check the number of whitespace tokens is right for size children,
+// a container with changed size is written compact
+func leptLayoutMatches(node *leptNodeLayout, size, tokens int) bool {
+	if size == 0 {
+		return len(node.ws) == 1
+	}
+	return len(node.ws) == tokens*size
+}
+
+func leptRawString(raw string) (string, bool) {
+	c := NewLeptContext(raw)
+	s, ok := LeptParseStringRaw(c)
+	return s, ok == LeptParseOK && c.eof()
+}
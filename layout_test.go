@@ -0,0 +1,52 @@
+package goleptjson
+
+import (
+	"testing"
+)
+
+func TestLeptStringifyWithLayout(t *testing.T) {
+	inputs := []string{
+		"null",
+		"  1.50e+2 \n",
+		"\"\\u0061\\/b\"",
+		"[ ]",
+		"{\n}",
+		"{\n  \"name\" : \"goleptjson\",\n  \"version\": 1.0,\n  \"tags\": [ \"json\",\"go\" ,\n\t\"parser\" ],\n  \"\\u006Fk\":true,\n  \"nested\" :{ \"a\" : [ [ ], { } ] }\n}\n",
+	}
+	for _, input := range inputs {
+		v := NewLeptValue()
+		event, layout := LeptParseWithLayout(v, input, nil)
+		expectEQLeptEvent(t, LeptParseOK, event)
+		expectEQString(t, input, LeptStringifyWithLayout(v, layout))
+	}
+
+	// comments around the root are kept with AllowComments
+	comments := []string{
+		"// head\n{\"a\": 1} // tail\n",
+		"/* head */ [ 1, /* inner */ 2 ]\n/* tail */",
+	}
+	for _, input := range comments {
+		v := NewLeptValue()
+		event, layout := LeptParseWithLayout(v, input, &LeptParseOptions{AllowComments: true})
+		expectEQLeptEvent(t, LeptParseOK, event)
+		expectEQString(t, input, LeptStringifyWithLayout(v, layout))
+	}
+
+	// only the changed regions lose the layout
+	input := "{\n  \"a\": 1.0,\n  \"b\": [ 1, 2 ],\n  \"c\": { \"d\" : \"x\" }\n}"
+	v := NewLeptValue()
+	event, layout := LeptParseWithLayout(v, input, nil)
+	expectEQLeptEvent(t, LeptParseOK, event)
+	LeptSetNumber(LeptFindObjectValue(v, "a"), 2)
+	expectEQString(t, "{\n  \"a\": 2,\n  \"b\": [ 1, 2 ],\n  \"c\": { \"d\" : \"x\" }\n}", LeptStringifyWithLayout(v, layout))
+	LeptSetString(LeptFindObjectValue(LeptFindObjectValue(v, "c"), "d"), "y")
+	expectEQString(t, "{\n  \"a\": 2,\n  \"b\": [ 1, 2 ],\n  \"c\": { \"d\" : \"y\" }\n}", LeptStringifyWithLayout(v, layout))
+	LeptSetString(LeptSetObjectValue(LeptFindObjectValue(v, "c"), "e"), "z")
+	expectEQString(t, "{\n  \"a\": 2,\n  \"b\": [ 1, 2 ],\n  \"c\": {\"d\":\"y\",\"e\":\"z\"}\n}", LeptStringifyWithLayout(v, layout))
+
+	// nil layout is compact
+	expectEQString(t, LeptStringify(v), LeptStringifyWithLayout(v, nil))
+	event, layout = LeptParseWithLayout(v, "[1,", nil)
+	expectEQLeptEvent(t, LeptParseExpectValue, event)
+	expectEQBool(t, true, layout == nil)
+}
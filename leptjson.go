@@ -70,8 +70,24 @@ const (
 	LeptParseMissColon
 	// LeptParseMissCommaOrCurlyBracket miss cooma or curly bracket
 	LeptParseMissCommaOrCurlyBracket
+
+	// for options
+
+	// LeptParseAstralChar code point above U+FFFF is not allowed by BMPOnly
+	LeptParseAstralChar
+	// LeptParseInvalidUTF8 string content is not valid utf8 with ValidateUTF8
+	LeptParseInvalidUTF8
+	// LeptParseExponentTooLarge exponent magnitude of number exceeds MaxExponent
+	LeptParseExponentTooLarge
+	// LeptParseDuplicateKey the key appears twice in an object with DisallowDuplicateKeys
+	LeptParseDuplicateKey
+	// LeptParseMaxDepth arrays and objects nest deeper than MaxDepth
+	LeptParseMaxDepth
 )
 
+// LeptParseMissCommaOrSquareBracket the correctly spelled alias of LeptParseMissCommaOrSouareBracket
+const LeptParseMissCommaOrSquareBracket = LeptParseMissCommaOrSouareBracket
+
 var eventNames = []string{
 	"LeptParseOK",
 	"LeptParseExpectValue",
@@ -87,10 +103,38 @@ var eventNames = []string{
 	"LeptParseMissKey",
 	"LeptParseMissColon",
 	"LeptParseMissCommaOrCurlyBracket",
+	"LeptParseAstralChar",
+	"LeptParseInvalidUTF8",
+	"LeptParseExponentTooLarge",
+	"LeptParseDuplicateKey",
+	"LeptParseMaxDepth",
+}
+
+// eventMessages the human readable messages of events used by LeptError, in the order of eventNames
+var eventMessages = []string{
+	"ok",
+	"expect value",
+	"invalid value",
+	"root not singular",
+	"number too big",
+	"miss quotation mark",
+	"invalid string escape",
+	"invalid string char",
+	"invalid unicode hex",
+	"invalid unicode surrogate",
+	"miss comma or square bracket",
+	"miss key",
+	"miss colon",
+	"miss comma or curly bracket",
+	"astral char",
+	"invalid utf8",
+	"exponent too large",
+	"duplicate key",
+	"max depth exceeded",
 }
 
 func (event LeptEvent) String() string {
-	if int(event) < len(eventNames) {
+	if event >= 0 && int(event) < len(eventNames) {
 		return eventNames[event]
 	}
 	return "LeptParseError"
@@ -105,6 +149,94 @@ func (event *LeptEvent) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// LeptError wrap the parse event of invalid json as an error
+type LeptError struct {
+	// Event the parse event, never be LeptParseOK
+	Event LeptEvent
+	// Offset the byte offset where parsing stopped
+	Offset int
+}
+
+func (e *LeptError) Error() string {
+	return fmt.Sprintf("goleptjson: %v at offset %v", LeptParseResultString(e.Event), e.Offset)
+}
+
+// LeptParseResultString return the human readable message of the parse event like "miss colon",
+// String of LeptEvent gives the name of constant instead
+func LeptParseResultString(event LeptEvent) string {
+	if event >= 0 && int(event) < len(eventMessages) {
+		return eventMessages[event]
+	}
+	return "parse error"
+}
+
+// leptSnippetContext the lines before and after the line of error in LeptErrorSnippet
+const leptSnippetContext = 2
+
+// LeptErrorSnippet return the lines around the offset of json with line numbers,
+// and a caret ^ under the offending character like a compiler error
+func LeptErrorSnippet(json string, offset int) string {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(json) {
+		offset = len(json)
+	}
+	lines, starts := leptSplitLines(json)
+	line := 0
+	for line < len(lines)-1 && starts[line+1] <= offset {
+		line++
+	}
+	first, last := line-leptSnippetContext, line+leptSnippetContext
+	if first < 0 {
+		first = 0
+	}
+	if last > len(lines)-1 {
+		last = len(lines) - 1
+	}
+	width := len(strconv.Itoa(last + 1))
+	var buf bytes.Buffer
+	for i := first; i <= last; i++ {
+		fmt.Fprintf(&buf, "%*d | %s\n", width, i+1, lines[i])
+		if i == line {
+			// the offset on a line break is put after the text
+			col := offset - starts[i]
+			if col > len(lines[i]) {
+				col = len(lines[i])
+			}
+			// keep the tabs so that the caret is aligned
+			var pad bytes.Buffer
+			for _, r := range lines[i][:col] {
+				if r == '\t' {
+					pad.WriteByte('\t')
+				} else {
+					pad.WriteByte(' ')
+				}
+			}
+			fmt.Fprintf(&buf, "%*s | %s^\n", width, "", pad.String())
+		}
+	}
+	return buf.String()
+}
+
+// leptSplitLines split s into lines without the line breaks and the offsets where they start,
+// a lone \r is a line break and \r\n is one like LeptParseWhitespace
+func leptSplitLines(s string) (lines []string, starts []int) {
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\r' && s[i] != '\n' {
+			continue
+		}
+		lines = append(lines, s[start:i])
+		starts = append(starts, start)
+		if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+			i++
+		}
+		start = i + 1
+	}
+	return append(lines, s[start:]), append(starts, start)
+}
+
 // LeptKeyNotExist object key not exist
 const LeptKeyNotExist int = -1
 
@@ -139,7 +271,7 @@ var typeNames = []string{
 }
 
 func (t LeptType) String() string {
-	if int(t) < len(typeNames) {
+	if t >= 0 && int(t) < len(typeNames) {
 		return typeNames[t]
 	}
 	return "LeptUnkown"
@@ -158,6 +290,10 @@ type LeptValue struct {
 	s   string
 	a   []*LeptValue  // for array
 	o   []*LeptMember // for object
+
+	text string // the number token as written with KeepNumberText, empty if not kept
+
+	shared bool // interned value shared by many parents, must not be mutated
 }
 
 // NewLeptValue return a init LeptValue
@@ -169,49 +305,140 @@ func NewLeptValue() *LeptValue {
 
 // LeptContext hold the input string
 type LeptContext struct {
-	json string
+	json string // the whole input
+	pos  int    // offset of the next byte to parse
+	opts *LeptParseOptions
+
+	warnings *leptWarnings // nil if warnings are not collected
+	layout   *LeptLayout   // nil if layout is not recorded
+
+	line      int // newlines consumed, only whitespace may hold a newline
+	lineStart int // offset of the first byte of the current line
+
+	depth int // arrays and objects open around the current value
+
+	buf bytes.Buffer // scratch of LeptParseStringRaw, reset for each string
 }
 
 // NewLeptContext return a init LeptContext
 func NewLeptContext(json string) *LeptContext {
 	return &LeptContext{
 		json: json,
+		opts: &defaultLeptParseOptions,
 	}
 }
 
+// leptOffset return the bytes consumed of the input
+func leptOffset(c *LeptContext) int {
+	return c.pos
+}
+
+// eof check the whole input is consumed
+func (c *LeptContext) eof() bool {
+	return c.pos >= len(c.json)
+}
+
+// peek return the next byte without consuming it, 0 at the end of input
+func (c *LeptContext) peek() byte {
+	if c.eof() {
+		return 0
+	}
+	return c.json[c.pos]
+}
+
+// next consume and return the next byte, 0 at the end of input
+func (c *LeptContext) next() byte {
+	ch := c.peek()
+	if !c.eof() {
+		c.pos++
+	}
+	return ch
+}
+
+// rest return the input not consumed yet
+func (c *LeptContext) rest() string {
+	return c.json[c.pos:]
+}
+
 func expect(c *LeptContext, ch byte) {
-	if len(c.json) == 0 {
+	if c.eof() {
 		panic(ErrReachEnd)
 	}
-	first := c.json[0]
-	if first != ch {
+	if c.next() != ch {
 		panic(ErrUnexpectChar)
 	}
-	c.json = c.json[1:]
 }
 
-// LeptParseWhitespace use to parse white space like '\t' '\n' '\r' ' '
+// LeptParseWhitespace use to parse white space like '\t' '\n' '\r' ' ', and the comments with AllowComments
 func LeptParseWhitespace(c *LeptContext) {
-	i := 0
-	n := len(c.json)
-	for i < n && (c.json[i] == ' ' || c.json[i] == '\t' || c.json[i] == '\n' || c.json[i] == '\r') {
-		i++
+	for {
+		switch c.peek() {
+		case '\n':
+			c.line++
+			c.lineStart = c.pos + 1
+		case '\r':
+			// \r\n is counted once by the \n
+			if c.pos+1 >= len(c.json) || c.json[c.pos+1] != '\n' {
+				c.line++
+				c.lineStart = c.pos + 1
+			}
+		case ' ', '\t':
+		case '/':
+			if !c.opts.AllowComments || !leptSkipComment(c) {
+				return
+			}
+			continue
+		default:
+			return
+		}
+		c.pos++
 	}
-	c.json = c.json[i:]
+}
+
+// leptSkipComment skip the // comment up to the \r or \n or the /* */ comment,
+// false if c is not at a complete comment and nothing is consumed
+func leptSkipComment(c *LeptContext) bool {
+	rest := c.rest()
+	if strings.HasPrefix(rest, "//") {
+		if i := strings.IndexAny(rest, "\r\n"); i >= 0 {
+			c.pos += i
+		} else {
+			c.pos = len(c.json)
+		}
+		return true
+	}
+	if !strings.HasPrefix(rest, "/*") {
+		return false
+	}
+	end := strings.Index(rest[len("/*"):], "*/")
+	if end < 0 {
+		return false
+	}
+	comment := rest[:len("/*")+end+len("*/")]
+	if i := strings.LastIndexAny(comment, "\r\n"); i >= 0 {
+		c.line += strings.Count(comment, "\n") + strings.Count(comment, "\r") - strings.Count(comment, "\r\n")
+		c.lineStart = c.pos + i + 1
+	}
+	c.pos += len(comment)
+	return true
+}
+
+// LeptGetErrorPosition return the 1-based line and byte column where parsing of c stopped,
+// after a failed parse it is where the error happened
+func LeptGetErrorPosition(c *LeptContext) (line, col int) {
+	if c == nil {
+		panic("LeptGetErrorPosition c is nil")
+	}
+	return c.line + 1, leptOffset(c) - c.lineStart + 1
 }
 
 // LeptParseNull use to parse "null"
 func LeptParseNull(c *LeptContext, v *LeptValue) LeptEvent {
 	expect(c, 'n')
-	n := len(c.json)
-	want := 4
-	if n < want-1 {
+	if !strings.HasPrefix(c.rest(), "ull") {
 		return LeptParseInvalidValue
 	}
-	if c.json[0] != 'u' || c.json[1] != 'l' || c.json[2] != 'l' {
-		return LeptParseInvalidValue
-	}
-	c.json = c.json[want-1:]
+	c.pos += len("ull")
 	v.typ = LeptNull
 	return LeptParseOK
 }
@@ -219,15 +446,10 @@ func LeptParseNull(c *LeptContext, v *LeptValue) LeptEvent {
 // LeptParseTrue use to parse "true"
 func LeptParseTrue(c *LeptContext, v *LeptValue) LeptEvent {
 	expect(c, 't')
-	n := len(c.json)
-	want := 4
-	if n < want-1 {
-		return LeptParseInvalidValue
-	}
-	if c.json[0] != 'r' || c.json[1] != 'u' || c.json[2] != 'e' {
+	if !strings.HasPrefix(c.rest(), "rue") {
 		return LeptParseInvalidValue
 	}
-	c.json = c.json[want-1:]
+	c.pos += len("rue")
 	v.typ = LeptTrue
 	return LeptParseOK
 }
@@ -235,15 +457,10 @@ func LeptParseTrue(c *LeptContext, v *LeptValue) LeptEvent {
 // LeptParseFalse use to parse "false"
 func LeptParseFalse(c *LeptContext, v *LeptValue) LeptEvent {
 	expect(c, 'f')
-	n := len(c.json)
-	want := 5
-	if n < want-1 {
+	if !strings.HasPrefix(c.rest(), "alse") {
 		return LeptParseInvalidValue
 	}
-	if c.json[0] != 'a' || c.json[1] != 'l' || c.json[2] != 's' || c.json[3] != 'e' {
-		return LeptParseInvalidValue
-	}
-	c.json = c.json[want-1:]
+	c.pos += len("alse")
 	v.typ = LeptFalse
 	return LeptParseOK
 }
@@ -251,299 +468,202 @@ func LeptParseFalse(c *LeptContext, v *LeptValue) LeptEvent {
 // LeptParseLiteral merge null true false
 func LeptParseLiteral(c *LeptContext, v *LeptValue, literal string, typ LeptType) LeptEvent {
 	expect(c, literal[0])
-	n := len(c.json)
-	want := len(literal)
-	if n < want-1 {
+	if !strings.HasPrefix(c.rest(), literal[1:]) {
 		return LeptParseInvalidValue
 	}
-	for i := 0; i < want-1; i++ {
-		if c.json[i] != literal[i+1] {
-			return LeptParseInvalidValue
-		}
-	}
-	c.json = c.json[want-1:]
+	c.pos += len(literal) - 1
 	v.typ = typ
 	return LeptParseOK
 }
 
 // LeptParseNumber use to parse "Number"
 func LeptParseNumber(c *LeptContext, v *LeptValue) LeptEvent {
-	var end string
-	var err error
-	// v.n, end, err = strtod(c.json)
-	v.n, end, err = strToFloat64(c.json)
+	// the text of a number parsed before is stale
+	v.text = ""
+	if c.opts.AllowNonFinite {
+		for _, token := range leptNonFiniteTokens {
+			if strings.HasPrefix(c.rest(), token.literal) {
+				c.pos += len(token.literal)
+				v.n = token.n
+				v.typ = LeptNumber
+				if c.opts.KeepNumberText {
+					v.text = token.literal
+				}
+				return LeptParseOK
+			}
+		}
+	}
+	if c.opts.AllowHexNumbers && (strings.HasPrefix(c.rest(), "0x") || strings.HasPrefix(c.rest(), "0X")) {
+		return leptParseHex(c, v)
+	}
+	end, ok := leptScanNumber(c.rest())
+	if !ok {
+		return LeptParseInvalidValue
+	}
+	literal := c.rest()[:end]
+	if c.opts.MaxExponent > 0 && leptExponentExceeds(literal, c.opts.MaxExponent) {
+		return LeptParseExponentTooLarge
+	}
+	parse := c.opts.NumberParser
+	if parse == nil {
+		parse = leptParseFloat
+	}
+	n, err := parse(literal)
 	if err != nil {
+		// the magnitude above math.MaxFloat64, the underflow to 0 or denormal is fine
+		if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange && c.opts.NumberParser == nil {
+			return LeptParseNumberTooBig
+		}
 		return LeptParseInvalidValue
 	}
-	c.json = end
+	if c.warnings != nil && leptLosesPrecision(literal, n) {
+		leptWarn(c, LeptWarningPrecisionLoss, literal)
+	}
+	c.pos += end
+	v.n = n
 	v.typ = LeptNumber
+	if c.opts.KeepNumberText {
+		v.text = literal
+	}
+	if c.layout != nil {
+		c.layout.node(v).raw = literal
+	}
 	return LeptParseOK
 }
 
-// strtod use to parse input string to a number
-func strtod(input string) (float64, string, error) {
-	// number = [ "-" ] int [ frac ] [ exp ]
-	// int = "0" / digit1-9 *digit
-	// frac = "." 1*digit
-	// exp = ("e" / "E") ["-" / "+"] 1*digit
-
-	// todo fix 浮点数溢出问题
-	first := input[0]
-	neg := false
-	if first == '-' {
-		neg = true
-		input = input[1:]
-	}
-	var ret float64 = 0
-	var integer int = 0
-	var decimal int = 0
-	var exp int = 0
-	var err error
-	n := len(input)
-	var IllegalInput = errors.New("illegal input number string")
-	if n == 0 {
-		// no more charater
-		return ret, "", IllegalInput
-	}
-	// take care of 0.0 0.12120
-	if input[0] == '0' && n == 1 {
-		// start with zero illegal like 0123
-		return ret, "", nil
-	}
-	if input[0] == '0' && n > 1 {
-		if input[1] == '.' {
-			// pass have to check fix frac
-		} else if input[1] == 'e' || input[1] == 'E' {
-			// pass have to check fix exp
-		} else if input[1] == 'x' || isDigit(input[1]) {
-			// fix of 0x0 ox123 0123 0abc
-			return ret, input, IllegalInput
-		}
-	}
-	// fix 1abc 1, 1x
-	if !isDigit(input[0]) {
-		// 非法开头字符
-		return ret, input, IllegalInput
-	}
-	input, integer, err = parseInteger(input)
+// leptParseHex parse the 0x prefixed integer of AllowHexNumbers, at least one hex digit after 0x
+func leptParseHex(c *LeptContext, v *LeptValue) LeptEvent {
+	rest := c.rest()
+	end := len("0x")
+	for end < len(rest) && leptHexValue(rest[end]) >= 0 {
+		end++
+	}
+	if end == len("0x") {
+		return LeptParseInvalidValue
+	}
+	literal := rest[:end]
+	u, err := strconv.ParseUint(literal[len("0x"):], 16, 64)
 	if err != nil {
-		return ret, input, err
-	}
-	n = len(input)
-	if n <= 0 {
-		// end just integer
-		ret = float64(integer)
-		if neg {
-			return -ret, input, nil
-		}
-		return ret, input, nil
-	}
-	// frac or exp
-	ret = float64(integer)
-	if input[0] == '.' {
-		// should be frac
-		input, decimal, err = parseFrac(input)
-		if err != nil {
-			return ret, input, err
-		}
-		var frac int = 1
-		for j := n - 1; j > len(input); j-- {
-			frac *= 10
-		}
-		// todo fix 浮点数溢出问题
-		ret += float64(decimal) / float64(frac)
-		if len(input) == 0 {
-			if neg {
-				return -ret, input, nil
-			}
-			return ret, input, nil
-		}
-		if !(input[0] == 'e' || input[0] == 'E') {
-			// following is not exp  do not parse any more leave it to next parser
-			return ret, input, nil
-		}
-		input, exp, err = parseExp(input)
-		if err != nil {
-			// illegal next char
-			return ret, input, IllegalInput
-		}
-		ret *= float64(math.Pow10(exp))
-		if neg {
-			return -ret, input, nil
-		}
-		return ret, input, nil
-	} else if input[0] == 'e' || input[0] == 'E' {
-		// should be exp
-		input, exp, err = parseExp(input)
-		if err != nil {
-			return ret, input, err
-		}
-		// do not parse any more leave it to next parser
-		ret *= float64(math.Pow10(exp))
-		if neg {
-			return -ret, input, nil
-		}
-		return ret, input, nil
-	} else {
-		// illegal next like 123abc 123 , 123, 123] 123} 123space 等情况
-		return ret, input, nil
+		return LeptParseNumberTooBig
+	}
+	n := float64(u)
+	// float64 hold the integers up to 2^53 exactly, 2^64 itself does not fit in uint64
+	if c.warnings != nil && u > 1<<53 && (n >= 1<<64 || uint64(n) != u) {
+		leptWarn(c, LeptWarningPrecisionLoss, literal)
+	}
+	c.pos += end
+	v.n = n
+	v.typ = LeptNumber
+	if c.opts.KeepNumberText {
+		v.text = literal
+	}
+	if c.layout != nil {
+		c.layout.node(v).raw = literal
 	}
+	return LeptParseOK
+}
+
+// leptNonFiniteTokens the numbers out of json grammar accepted by AllowNonFinite
+var leptNonFiniteTokens = []struct {
+	literal string
+	n       float64
+}{
+	{"NaN", math.NaN()},
+	{"Infinity", math.Inf(1)},
+	{"-Infinity", math.Inf(-1)},
+}
+
+// leptExponentExceeds check the magnitude of the exponent part of a scanned number literal
+func leptExponentExceeds(literal string, max int) bool {
+	i := strings.IndexAny(literal, "eE")
+	if i < 0 {
+		return false
+	}
+	// the digits are checked by leptScanNumber, stop as soon as it exceeds so that it never overflows
+	exp := 0
+	for _, d := range strings.TrimLeft(literal[i+1:], "+-") {
+		if exp > max/10 {
+			return true
+		}
+		exp = exp*10 + int(d-'0')
+		if exp > max {
+			return true
+		}
+	}
+	return false
+}
+
+// leptParseFloat the default number parser
+func leptParseFloat(literal string) (float64, error) {
+	return strconv.ParseFloat(literal, 64)
+}
+
+// strtod use to parse input string to a number, the grammar is checked by leptScanNumber
+// and the conversion is left to strconv.ParseFloat, rest is the input after the number
+func strtod(input string) (float64, string, error) {
+	return strToFloat64(input)
 }
 
 func strToFloat64(input string) (float64, string, error) {
+	end, ok := leptScanNumber(input)
+	if !ok {
+		return 0, input, errors.New("illegal input number string")
+	}
+	ret, err := strconv.ParseFloat(input[:end], 64)
+	return ret, input[end:], err
+}
+
+// leptScanNumber return the length of the number at the start of input, false if it is illegal
+func leptScanNumber(input string) (int, bool) {
 	// number = [ "-" ] int [ frac ] [ exp ]
 	// int = "0" / digit1-9 *digit
 	// frac = "." 1*digit
 	// exp = ("e" / "E") ["-" / "+"] 1*digit
-
-	// fix 浮点数溢出问题
-	origin := input
-	end := 0
-	if input[0] == '-' {
-		input = input[1:]
-		end++
+	i, n := 0, len(input)
+	if i < n && input[i] == '+' {
+		// json has no plus sign before the number, strconv.ParseFloat would take it
+		return 0, false
 	}
-	var IllegalInput = errors.New("illegal input number string")
-	if len(input) == 0 {
-		// no more charater
-		return 0, "", IllegalInput
-	}
-	// take care of 0.0 0.12120
-	if input[0] == '0' && len(input) == 1 {
-		// start with zero illegal like 0123
-		return 0, "", nil
-	}
-	if input[0] == '0' && len(input) > 1 {
-		if input[1] == '.' {
-			// pass have to check fix frac
-		} else if input[1] == 'e' || input[1] == 'E' {
-			// pass have to check fix exp
-		} else if input[1] == 'x' || isDigit(input[1]) {
-			// fix of 0x0 ox123 0123 0abc
-			return 0, input, IllegalInput
-		}
-	}
-	// fix abc123 c1, x321
-	if !isDigit(input[0]) {
-		// 非法开头字符
-		return 0, input, IllegalInput
-	}
-	i := 0
-	for i < len(input) && isDigit(input[i]) {
+	if i < n && input[i] == '-' {
 		i++
 	}
-	input = input[i:]
-	end += i
-	if len(input) > 0 && input[0] == '.' {
-		// should be frac
-		if len(input) == 1 {
-			return 0, input, IllegalInput
+	if i >= n || !isDigit(input[i]) {
+		// 非法开头字符 like abc +1 .123
+		return 0, false
+	}
+	if input[i] == '0' {
+		i++
+		// fix of 0x0 ox123 0123
+		if i < n && (input[i] == 'x' || isDigit(input[i])) {
+			return 0, false
 		}
-		i = 1
-		for i < len(input) && isDigit(input[i]) {
+	} else {
+		for i < n && isDigit(input[i]) {
 			i++
 		}
-		end += i
-		input = input[i:]
-		if len(input) > 0 && (input[0] == 'e' || input[0] == 'E') {
-			if len(input) == 1 {
-				return 0, input, IllegalInput
-			}
-			end++
-			input = input[1:]
-			if input[0] == '-' || input[0] == '+' {
-				input = input[1:]
-				end++
-			}
-			if len(input) == 0 {
-				return 0, input, IllegalInput
-			}
-			i = 0
-			for i < len(input) && isDigit(input[i]) {
-				i++
-			}
-			end += i
-			input = input[i:]
-			ret, err := strconv.ParseFloat(origin[:end], 64)
-			return ret, input, err
-		}
-		ret, err := strconv.ParseFloat(origin[:end], 64)
-		return ret, input, err
-	} else if len(input) > 0 && (input[0] == 'e' || input[0] == 'E') {
-		if len(input) == 1 {
-			return 0, input, IllegalInput
-		}
-		end++
-		input = input[1:]
-		if input[0] == '-' || input[0] == '+' {
-			input = input[1:]
-			end++
-		}
-		if len(input) == 0 {
-			return 0, input, IllegalInput
+	}
+	if i < n && input[i] == '.' {
+		i++
+		if i >= n || !isDigit(input[i]) {
+			return 0, false
 		}
-		i = 0
-		for i < len(input) && isDigit(input[i]) {
+		for i < n && isDigit(input[i]) {
 			i++
 		}
-		end += i
-		input = input[i:]
-		ret, err := strconv.ParseFloat(origin[:end], 64)
-		return ret, input, err
-	} else {
-		ret, err := strconv.ParseFloat(origin[:end], 64)
-		return ret, input, err
 	}
-}
-
-func parseExp(input string) (string, int, error) {
-	if input[0] == 'e' || input[0] == 'E' {
-		// should be exp
-		if len(input) == 1 {
-			// just e E illegal
-			return "", 0, errors.New("input is not a exp")
-		}
-		expNeg := false
-		if input[1] == '-' || input[1] == '+' {
-			expNeg = input[1] == '-'
-			input = input[2:]
-		} else {
-			input = input[1:]
+	if i < n && (input[i] == 'e' || input[i] == 'E') {
+		i++
+		if i < n && (input[i] == '-' || input[i] == '+') {
+			i++
 		}
-		// get exp
-		input, exp, err := parseInteger(input)
-		if err != nil {
-			return "", 0, err
+		if i >= n || !isDigit(input[i]) {
+			return 0, false
 		}
-		if expNeg {
-			return input, -exp, err
+		for i < n && isDigit(input[i]) {
+			i++
 		}
-		return input, exp, err
 	}
-	return "", 0, errors.New("input is not a exp")
-}
-func parseFrac(input string) (string, int, error) {
-	if len(input) == 1 {
-		return "", 0, errors.New("input is not a frac")
-	}
-	if input[0] == '.' {
-		// should be frac
-		return parseInteger(input[1:])
-	}
-	return "", 0, errors.New("input is not a frac")
-}
-func parseInteger(input string) (string, int, error) {
-	i := 0
-	n := len(input)
-	for i < n && isDigit(input[i]) {
-		// get the integer
-		i++
-	}
-	integer, err := strconv.Atoi(input[:i])
-	if err != nil {
-		return "", 0, err
-	}
-	return input[i:], integer, nil
+	return i, true
 }
 
 func isDigit(char byte) bool {
@@ -556,11 +676,15 @@ func isDigit1to9(char byte) bool {
 
 // LeptParseString use to parse string include \u
 func LeptParseString(c *LeptContext, v *LeptValue) LeptEvent {
+	start := c.pos
 	s, ok := LeptParseStringRaw(c)
 	if ok != LeptParseOK {
 		return ok
 	}
 	LeptSetString(v, s)
+	if c.layout != nil {
+		c.layout.node(v).raw = c.json[start:c.pos]
+	}
 	return ok
 }
 
@@ -582,13 +706,14 @@ func LeptParseString(c *LeptContext, v *LeptValue) LeptEvent {
 // unescaped = %x20-21 / %x23-5B / %x5D-10FFFF
 func LeptParseStringRaw(c *LeptContext) (string, LeptEvent) {
 	expect(c, '"')
-	var stack bytes.Buffer
-	defer stack.Truncate(0)
-	for i, n := 0, len(c.json); i < n; i++ {
+	// a string never nests, so one scratch serves the keys and values of the whole document
+	stack := &c.buf
+	stack.Reset()
+	for i, n := c.pos, len(c.json); i < n; i++ {
 		ch := c.json[i]
 		switch ch {
 		case '"':
-			c.json = c.json[i+1:]
+			c.pos = i + 1
 			return stack.String(), LeptParseOK
 		case '\\':
 			// 遇到第一个转义符号，需要连续匹配两个 \
@@ -665,30 +790,33 @@ func LeptParseStringRaw(c *LeptContext) (string, LeptEvent) {
 					return "", LeptParseInvalidUnicodeHex
 				}
 				if utf16.IsSurrogate(rr) {
-					if i+6 >= n || c.json[i+6] != '\\' {
-						return "", LeptParseInvalidUnicodeSurrogate
+					if rr < 0xDC00 && i+7 < n && c.json[i+6] == '\\' && c.json[i+7] == 'u' {
+						if rr1 := getu4(c.json[i+8:]); rr1 >= 0xDC00 && rr1 <= 0xDFFF {
+							if c.opts.BMPOnly {
+								return "", LeptParseAstralChar
+							}
+							stack.WriteRune(utf16.DecodeRune(rr, rr1))
+							i += 10
+							// 这里的 break 是跳出 最近一层的 switch 所以需要加上下面的 i += 4
+							break
+						}
 					}
-					if i+7 >= n || c.json[i+7] != 'u' {
+					// lone or malformed surrogate
+					switch c.opts.SurrogatePolicy {
+					case LeptSurrogateReplace:
+						rr = unicode.ReplacementChar
+					case LeptSurrogatePassthrough:
+						// WTF-8, utf8.EncodeRune refuses the surrogate
+						stack.WriteByte(byte(0xE0 | rr>>12))
+						stack.WriteByte(byte(0x80 | (rr>>6)&0x3F))
+						stack.WriteByte(byte(0x80 | rr&0x3F))
+						i += 5
+						continue
+					default:
 						return "", LeptParseInvalidUnicodeSurrogate
 					}
-					rr1 := getu4(c.json[i+8:])
-					if rr1 < 0xDC00 || rr1 > 0xDFFF {
-						return "", LeptParseInvalidUnicodeSurrogate
-					}
-					if dec := utf16.DecodeRune(rr, rr1); dec != unicode.ReplacementChar {
-						bits := make([]byte, 8)
-						w := utf8.EncodeRune(bits, dec)
-						stack.Write(bits[:w])
-						i += 10
-						// 这里的 break 是跳出 最近一层的 switch 所以需要加上下面的 i += 4
-						break
-					}
-					// Invalid surrogate; fall back to replacement rune.
-					rr = unicode.ReplacementChar
 				}
-				bits := make([]byte, 8)
-				w := utf8.EncodeRune(bits, rr)
-				stack.Write(bits[:w])
+				stack.WriteRune(rr)
 				i += 4
 			default:
 				return "", LeptParseInvalidStringEscape
@@ -701,6 +829,26 @@ func LeptParseStringRaw(c *LeptContext) (string, LeptEvent) {
 			if ch < 0x20 {
 				return "", LeptParseInvalidStringChar
 			}
+			if ch >= utf8.RuneSelf && c.opts.ValidateUTF8 {
+				r, size := utf8.DecodeRuneInString(c.json[i:])
+				if r == utf8.RuneError && size == 1 {
+					// stop at the invalid sequence so that the offset points to it
+					c.pos = i
+					return "", LeptParseInvalidUTF8
+				}
+				if size == 4 && c.opts.BMPOnly {
+					return "", LeptParseAstralChar
+				}
+				stack.WriteString(c.json[i : i+size])
+				i += size - 1
+				continue
+			}
+			// 4 bytes utf8 sequence encode the code point above U+FFFF, 0xF5-0xFF never start one
+			if ch >= 0xF0 && c.opts.BMPOnly {
+				if _, size := utf8.DecodeRuneInString(c.json[i:]); size == 4 {
+					return "", LeptParseAstralChar
+				}
+			}
 			stack.WriteByte(ch)
 		}
 	}
@@ -745,65 +893,107 @@ func getu4(input string) rune {
 
 // LeptParseValue use to parse value switch to spec func
 func LeptParseValue(c *LeptContext, v *LeptValue) LeptEvent {
-	n := len(c.json)
-	if n == 0 {
+	if c.eof() {
 		return LeptParseExpectValue
 	}
-	switch c.json[0] {
-	case 'n':
-		return LeptParseNull(c, v)
-	case 't':
-		return LeptParseTrue(c, v)
-	case 'f':
-		return LeptParseFalse(c, v)
+	switch c.peek() {
+	case 'n', 't', 'f':
+		start := c.pos
+		var event LeptEvent
+		switch c.peek() {
+		case 'n':
+			event = LeptParseNull(c, v)
+		case 't':
+			event = LeptParseTrue(c, v)
+		default:
+			event = LeptParseFalse(c, v)
+		}
+		if event != LeptParseOK {
+			// stop at the start of literal so that the offset points to it
+			c.pos = start
+		}
+		return event
 	case '"':
 		return LeptParseString(c, v)
-	case '[':
-		return LeptParseArray(c, v)
-	case '{':
-		return LeptParseObject(c, v)
+	case '[', '{':
+		if c.opts.MaxDepth > 0 && c.depth >= c.opts.MaxDepth {
+			// the offset points to the bracket one level too deep
+			return LeptParseMaxDepth
+		}
+		c.depth++
+		var event LeptEvent
+		if c.peek() == '[' {
+			event = LeptParseArray(c, v)
+		} else {
+			event = LeptParseObject(c, v)
+		}
+		c.depth--
+		return event
 	default:
 		return LeptParseNumber(c, v)
 	}
 }
 
+// leptParseElement parse an element of array or object
+func leptParseElement(c *LeptContext) (*LeptValue, LeptEvent) {
+	if c.opts.InternSmallIntegers && c.opts.NumberParser == nil && !c.opts.KeepNumberText && c.layout == nil && isDigit(c.peek()) {
+		if vi, rest := leptInternNumber(c.rest()); vi != nil {
+			c.pos = len(c.json) - len(rest)
+			return vi, LeptParseOK
+		}
+	}
+	vi := NewLeptValue()
+	return vi, LeptParseValue(c, vi)
+}
+
 // LeptParseArray use to parse array
 func LeptParseArray(c *LeptContext, v *LeptValue) LeptEvent {
 	// array = %x5B ws [ value *( ws %x2C ws value ) ] ws %x5D
 	expect(c, '[')
-	LeptParseWhitespace(c)
-	n := len(c.json)
-	if n == 0 {
-		return LeptParseMissCommaOrSouareBracket
+	var ws []string
+	ws = leptLayoutWhitespace(c, ws)
+	if c.eof() {
+		return leptPartial(c, v, LeptArray, LeptParseMissCommaOrSouareBracket)
 	}
-	if c.json[0] == ']' {
+	if c.peek() == ']' {
 		v.typ = LeptArray
 		v.a = make([]*LeptValue, 0)
-		c.json = c.json[1:]
+		c.pos++
+		leptLayoutContainer(c, v, ws, nil)
 		return LeptParseOK
 	}
 	for {
 		// LeptParseWhitespace(c) // my
-		vi := NewLeptValue()
-		if ok := LeptParseValue(c, vi); ok != LeptParseOK {
-			return ok
+		vi, ok := leptParseElement(c)
+		if ok != LeptParseOK {
+			if c.opts.BestEffort && (vi.typ == LeptArray || vi.typ == LeptObject) {
+				v.a = append(v.a, vi)
+			}
+			return leptPartial(c, v, LeptArray, ok)
 		}
 		v.a = append(v.a, vi)
 		// LeptParseWhitespace(c) //my
 		// 教程中的解析 空格 时有道理的，需要在值之后解析 ws。具体参考对应的 regex 定义
-		LeptParseWhitespace(c) // tutorial
-		if len(c.json) == 0 {
-			return LeptParseMissCommaOrSouareBracket
-		}
-		if c.json[0] == ',' {
-			c.json = c.json[1:]
-			LeptParseWhitespace(c) // tutorial
-		} else if c.json[0] == ']' {
-			c.json = c.json[1:]
+		ws = leptLayoutWhitespace(c, ws) // tutorial
+		if c.eof() {
+			return leptPartial(c, v, LeptArray, LeptParseMissCommaOrSouareBracket)
+		}
+		if c.peek() == ',' {
+			c.pos++
+			ws = leptLayoutWhitespace(c, ws) // tutorial
+			if c.opts.AllowTrailingComma && c.peek() == ']' {
+				c.pos++
+				v.typ = LeptArray
+				leptLayoutContainer(c, v, ws, nil)
+				return LeptParseOK
+			}
+		} else if c.peek() == ']' {
+			c.pos++
 			v.typ = LeptArray
+			leptLayoutContainer(c, v, ws, nil)
 			return LeptParseOK
 		} else {
-			return LeptParseMissCommaOrSouareBracket
+			return leptPartial(c, v, LeptArray, LeptParseMissCommaOrSouareBracket)
 		}
 	}
 }
@@ -813,57 +1003,147 @@ func LeptParseObject(c *LeptContext, v *LeptValue) LeptEvent {
 	// member = string ws %x3A ws value
 	// object = %x7B ws [ member *( ws %x2C ws member ) ] ws %x7D
 	expect(c, '{')
-	LeptParseWhitespace(c)
-	n := len(c.json)
-	if n == 0 {
-		return LeptParseMissCommaOrCurlyBracket
+	var ws, keys []string
+	ws = leptLayoutWhitespace(c, ws)
+	if c.eof() {
+		return leptPartial(c, v, LeptObject, LeptParseMissCommaOrCurlyBracket)
 	}
-	if c.json[0] == '}' {
+	if c.peek() == '}' {
 		v.typ = LeptObject
 		v.a = make([]*LeptValue, 0)
 		v.o = make([]*LeptMember, 0)
-		c.json = c.json[1:]
+		c.pos++
+		leptLayoutContainer(c, v, ws, nil)
 		return LeptParseOK
 	}
+	// the keys parsed so far, only when duplicate keys are checked
+	var seen map[string]bool
+	if c.opts.DisallowDuplicateKeys || c.warnings != nil {
+		seen = make(map[string]bool)
+	}
 	for {
-		if len(c.json) == 0 || c.json[0] != '"' {
-			return LeptParseMissKey
+		if c.peek() != '"' {
+			return leptPartial(c, v, LeptObject, LeptParseMissKey)
 		}
+		offset := leptOffset(c)
 		ki, ok := LeptParseStringRaw(c)
 		if ok != LeptParseOK {
-			return ok
+			return leptPartial(c, v, LeptObject, ok)
+		}
+		if c.layout != nil {
+			keys = append(keys, c.json[offset:c.pos])
+		}
+		if seen[ki] {
+			if c.opts.DisallowDuplicateKeys {
+				// stop at the start of key so that the offset points to it
+				c.pos = offset
+				return leptPartial(c, v, LeptObject, LeptParseDuplicateKey)
+			}
+			if c.warnings != nil {
+				c.warnings.add(LeptWarningDuplicateKey, offset, ki)
+			}
+		} else if seen != nil {
+			seen[ki] = true
 		}
 		// "":  23456789012E66, // fix 允许 key 为空字符串
 		// if len(ki) == 0 {
 		// 	return LeptParseMissKey
 		// }
-		LeptParseWhitespace(c)
-		if c.json[0] != ':' {
-			return LeptParseMissColon
+		ws = leptLayoutWhitespace(c, ws)
+		if c.peek() != ':' {
+			return leptPartial(c, v, LeptObject, LeptParseMissColon)
 		}
-		c.json = c.json[1:]
-		LeptParseWhitespace(c)
-		vi := NewLeptValue()
-		if ok := LeptParseValue(c, vi); ok != LeptParseOK {
-			return ok
+		c.pos++
+		ws = leptLayoutWhitespace(c, ws)
+		vi, ok := leptParseElement(c)
+		if ok != LeptParseOK {
+			if c.opts.BestEffort && (vi.typ == LeptArray || vi.typ == LeptObject) {
+				v.o = append(v.o, &LeptMember{key: ki, value: vi})
+			}
+			return leptPartial(c, v, LeptObject, ok)
 		}
 		v.o = append(v.o, &LeptMember{key: ki, value: vi})
 		// 教程中的解析 空格 时有道理的，需要在值之后解析 ws。具体参考对应的 regex 定义
+		ws = leptLayoutWhitespace(c, ws)
+		if c.eof() {
+			return leptPartial(c, v, LeptObject, LeptParseMissCommaOrCurlyBracket)
+		}
+		if c.peek() == ',' {
+			c.pos++
+			ws = leptLayoutWhitespace(c, ws)
+			if c.opts.AllowTrailingComma && c.peek() == '}' {
+				c.pos++
+				v.typ = LeptObject
+				leptLayoutContainer(c, v, ws, keys)
+				return LeptParseOK
+			}
+		} else if c.peek() == '}' {
+			c.pos++
+			v.typ = LeptObject
+			leptLayoutContainer(c, v, ws, keys)
+			return LeptParseOK
+		} else {
+			return leptPartial(c, v, LeptObject, LeptParseMissCommaOrCurlyBracket)
+		}
+	}
+}
+
+// leptPartial keep the partially parsed container v with BestEffort, and return the event
+func leptPartial(c *LeptContext, v *LeptValue, typ LeptType, event LeptEvent) LeptEvent {
+	if c.opts.BestEffort {
+		v.typ = typ
+		if typ == LeptArray && v.a == nil {
+			v.a = make([]*LeptValue, 0)
+		}
+		if typ == LeptObject && v.o == nil {
+			v.o = make([]*LeptMember, 0)
+		}
+	}
+	return event
+}
+
+// LeptParseArrayHead parse only the first n elements of the top level array and stop scanning,
+// the rest of json after the nth element is never read
+func LeptParseArrayHead(json string, n int) (*LeptValue, LeptEvent) {
+	c := NewLeptContext(json)
+	LeptParseWhitespace(c)
+	if c.eof() {
+		return nil, LeptParseExpectValue
+	}
+	if c.peek() != '[' {
+		return nil, LeptParseInvalidValue
+	}
+	expect(c, '[')
+	v := NewLeptValue()
+	v.typ = LeptArray
+	v.a = make([]*LeptValue, 0)
+	LeptParseWhitespace(c)
+	if c.peek() == ']' {
+		return v, LeptParseOK
+	}
+	for len(v.a) < n {
+		vi, ok := leptParseElement(c)
+		if ok != LeptParseOK {
+			return nil, ok
+		}
+		v.a = append(v.a, vi)
+		if len(v.a) == n {
+			break
+		}
 		LeptParseWhitespace(c)
-		if len(c.json) == 0 {
-			return LeptParseMissCommaOrCurlyBracket
+		if c.eof() {
+			return nil, LeptParseMissCommaOrSouareBracket
 		}
-		if c.json[0] == ',' {
-			c.json = c.json[1:]
+		if c.peek() == ',' {
+			c.pos++
 			LeptParseWhitespace(c)
-		} else if c.json[0] == '}' {
-			c.json = c.json[1:]
-			v.typ = LeptObject
-			return LeptParseOK
+		} else if c.peek() == ']' {
+			break
 		} else {
-			return LeptParseMissCommaOrCurlyBracket
+			return nil, LeptParseMissCommaOrSouareBracket
 		}
 	}
+	return v, LeptParseOK
 }
 
 // LeptParse use to parse value the enter
@@ -871,16 +1151,51 @@ func LeptParse(v *LeptValue, json string) LeptEvent {
 	if v == nil {
 		panic("LeptParse v is nil")
 	}
+	return leptParse(NewLeptContext(json), v)
+}
+
+// LeptParseErr use to parse value, report the failure as *LeptError, nil on success
+func LeptParseErr(v *LeptValue, json string) error {
+	if v == nil {
+		panic("LeptParseErr v is nil")
+	}
+	return LeptParseErrWithOptions(v, json, nil)
+}
+
+// LeptParseConsumed use to parse the value at the start of json, the rest after it is not checked,
+// consumed is the offset just past the value before any whitespace, or where parsing stopped on failure
+func LeptParseConsumed(v *LeptValue, json string) (LeptEvent, int) {
+	if v == nil {
+		panic("LeptParseConsumed v is nil")
+	}
+	leptCheckMutable(v, "LeptParseConsumed")
+	LeptFree(v)
 	c := NewLeptContext(json)
-	v.typ = LeptNull
 	LeptParseWhitespace(c)
+	event := LeptParseValue(c, v)
+	return event, leptOffset(c)
+}
+
+// leptParse parse the whole input of c into v, c stays where parsing stopped
+func leptParse(c *LeptContext, v *LeptValue) LeptEvent {
+	leptCheckMutable(v, "LeptParse")
+	LeptFree(v)
+	ws := leptLayoutWhitespace(c, nil)
 	if ret := LeptParseValue(c, v); ret != LeptParseOK {
 		return ret
 	}
-	LeptParseWhitespace(c)
-	if len(c.json) != 0 {
+	if !c.opts.DisallowTrailingWhitespace {
+		ws = leptLayoutWhitespace(c, ws)
+	}
+	if !c.eof() {
 		return LeptParseRootNotSingular
 	}
+	if c.layout != nil {
+		c.layout.before = ws[0]
+		if len(ws) > 1 {
+			c.layout.after = ws[1]
+		}
+	}
 	return LeptParseOK
 }
 
@@ -899,12 +1214,14 @@ func LeptInit(v *LeptValue) {
 
 // LeptFree free the memory
 func LeptFree(v *LeptValue) {
+	leptCheckMutable(v, "LeptFree")
 	// v = NewLeptValue()
 	v.typ = LeptNull
 	v.n = 0.0
 	v.s = ""
 	v.a = nil
 	v.o = nil
+	v.text = ""
 }
 
 // LeptSetNull use to set the type of null
@@ -912,6 +1229,7 @@ func LeptSetNull(v *LeptValue) {
 	if v == nil {
 		panic("LeptGetNumber v is nil or typ is not LeptNumber")
 	}
+	leptCheckMutable(v, "LeptSetNull")
 	v.typ = LeptNull
 }
 
@@ -923,13 +1241,39 @@ func LeptGetNumber(v *LeptValue) float64 {
 	return v.n
 }
 
+// LeptGetInteger use to get the number as int64, false if it has fractional part or is out of the range of int64
+func LeptGetInteger(v *LeptValue) (int64, bool) {
+	if v == nil || v.typ != LeptNumber {
+		panic("LeptGetInteger v is nil or typ is not LeptNumber")
+	}
+	// -2^63 is exact in float64 while 2^63-1 rounds up to 2^63
+	if v.n != math.Trunc(v.n) || v.n < math.MinInt64 || v.n >= -math.MinInt64 {
+		return 0, false
+	}
+	return int64(v.n), true
+}
+
 // LeptSetNumber use to set the type of value
 func LeptSetNumber(v *LeptValue, n float64) {
 	if v == nil {
 		panic("LeptSetNumber v is nil ")
 	}
+	leptCheckMutable(v, "LeptSetNumber")
 	v.n = n
 	v.typ = LeptNumber
+	v.text = ""
+}
+
+// LeptGetNumberText use to get the number token as written if it is kept by KeepNumberText,
+// or else the number as LeptStringify writes it
+func LeptGetNumberText(v *LeptValue) string {
+	if v == nil || v.typ != LeptNumber {
+		panic("LeptGetNumberText v is nil or typ is not LeptNumber")
+	}
+	if v.text != "" {
+		return v.text
+	}
+	return leptStringifyNumber(v.n)
 }
 
 // LeptGetBoolean use to get the type of value
@@ -948,6 +1292,7 @@ func LeptSetBoolean(v *LeptValue, n int) {
 	if v == nil {
 		panic("LeptSetBoolean v is nil ")
 	}
+	leptCheckMutable(v, "LeptSetBoolean")
 	if n == 0 {
 		v.typ = LeptFalse
 	} else {
@@ -963,6 +1308,14 @@ func LeptGetStringLength(v *LeptValue) int {
 	return len(v.s)
 }
 
+// LeptGetStringRuneLength use to get the rune count of string, the invalid utf8 byte counts as one
+func LeptGetStringRuneLength(v *LeptValue) int {
+	if v == nil || v.typ != LeptString {
+		panic("LeptGetStringRuneLength v is nil or typ is not string")
+	}
+	return utf8.RuneCountInString(v.s)
+}
+
 // LeptGetString use to get the type of value
 func LeptGetString(v *LeptValue) string {
 	if v == nil || v.typ != LeptString {
@@ -971,11 +1324,29 @@ func LeptGetString(v *LeptValue) string {
 	return v.s
 }
 
+// LeptGetStringBytes use to get the content of string as bytes, it is a copy which never aliases the value,
+// so the caller may modify it freely
+func LeptGetStringBytes(v *LeptValue) []byte {
+	if v == nil || v.typ != LeptString {
+		panic("LeptGetStringBytes v is nil or typ is not string")
+	}
+	return []byte(v.s)
+}
+
+// LeptEqualString check v is a string equal to s, false for the other types
+func LeptEqualString(v *LeptValue, s string) bool {
+	if v == nil {
+		panic("LeptEqualString v is nil")
+	}
+	return v.typ == LeptString && v.s == s
+}
+
 // LeptSetString use to get the type of value
 func LeptSetString(v *LeptValue, s string) {
 	if v == nil {
 		panic("LeptSetString v is nil")
 	}
+	leptCheckMutable(v, "LeptSetString")
 	v.s = s
 	v.typ = LeptString
 }
@@ -985,16 +1356,16 @@ func LeptGetArrayElement(v *LeptValue, index int) *LeptValue {
 	if v == nil || v.typ != LeptArray {
 		panic("LeptGetArrayElement v is nil or typ is not array")
 	}
-	if len(v.a) <= index {
-		panic("LeptGetArrayElement v length <= input index")
+	if index < 0 || len(v.a) <= index {
+		panic("LeptGetArrayElement input index is out of range")
 	}
-	return v.a[index]
+	return leptUnshare(&v.a[index])
 }
 
 // LeptGetArraySize use to get the size of array
 func LeptGetArraySize(v *LeptValue) int {
 	if v == nil || v.typ != LeptArray {
-		panic("LeptGetArrayElement v is nil or typ is not array")
+		panic("LeptGetArraySize v is nil or typ is not array")
 	}
 	return len(v.a)
 }
@@ -1012,8 +1383,8 @@ func LeptGetObjectKey(v *LeptValue, index int) string {
 	if v == nil || v.typ != LeptObject {
 		panic("LeptGetObjectKey v is nil or typ is not object")
 	}
-	if len(v.o) <= index {
-		panic("LeptGetObjectKey v length <= input index")
+	if index < 0 || len(v.o) <= index {
+		panic("LeptGetObjectKey input index is out of range")
 	}
 	member := v.o[index]
 	return member.key
@@ -1024,8 +1395,8 @@ func LeptGetObjectKeyLength(v *LeptValue, index int) int {
 	if v == nil || v.typ != LeptObject {
 		panic("LeptGetObjectKeyLength v is nil or typ is not object")
 	}
-	if len(v.o) <= index {
-		panic("LeptGetObjectKeyLength v length <= input index")
+	if index < 0 || len(v.o) <= index {
+		panic("LeptGetObjectKeyLength input index is out of range")
 	}
 	member := v.o[index]
 	return len(member.key)
@@ -1036,19 +1407,32 @@ func LeptGetObjectValue(v *LeptValue, index int) *LeptValue {
 	if v == nil || v.typ != LeptObject {
 		panic("LeptGetObjectValue v is nil or typ is not object")
 	}
-	if len(v.o) <= index {
-		panic("LeptGetObjectValue v length <= input index")
+	if index < 0 || len(v.o) <= index {
+		panic("LeptGetObjectValue input index is out of range")
 	}
-	member := v.o[index]
-	return member.value
+	return leptUnshare(&v.o[index].value)
 }
 
 // LeptStringify 得到紧凑的数据 string
 func LeptStringify(v *LeptValue) string {
-	return leptStringifyValue(v)
+	return leptStringifyValue(v, nil)
+}
+
+// LeptNumberRoundTrips check the number s parse, stringify and parse again get the same float64
+func LeptNumberRoundTrips(s string) bool {
+	v := NewLeptValue()
+	if LeptParse(v, s) != LeptParseOK || v.typ != LeptNumber {
+		return false
+	}
+	rv := NewLeptValue()
+	if LeptParse(rv, LeptStringify(v)) != LeptParseOK || rv.typ != LeptNumber {
+		return false
+	}
+	return math.Float64bits(v.n) == math.Float64bits(rv.n)
 }
 
-func leptStringifyValue(v *LeptValue) string {
+// leptStringifyValue nil opts is the format of LeptStringify
+func leptStringifyValue(v *LeptValue, opts *LeptStringifyOptions) string {
 	switch v.typ {
 	case LeptNull:
 		return "null"
@@ -1057,19 +1441,29 @@ func leptStringifyValue(v *LeptValue) string {
 	case LeptTrue:
 		return "true"
 	case LeptNumber:
-		// return strconv.FormatFloat(v.n, 'g', -1, 64)
-		return strconv.FormatFloat(v.n, 'g', 17, 64)
+		if opts != nil {
+			return opts.formatNumber(v.n)
+		}
+		if v.text != "" {
+			return v.text
+		}
+		return leptStringifyNumber(v.n)
 	case LeptString:
 		return leptStringifyString(v.s)
 	case LeptArray:
-		return leptStringifyArray(v)
+		return leptStringifyArray(v, opts)
 	case LeptObject:
-		return leptStringifyObject(v)
+		return leptStringifyObject(v, opts)
 	default:
 		panic("leptStringifyValue invalid type")
 	}
 }
 
+// leptStringifyNumber write n in the shortest form which round trips, the same as the default options
+func leptStringifyNumber(n float64) string {
+	return defaultLeptStringifyOptions.formatNumber(n)
+}
+
 // leptStringifyString 考虑转义符号 unicode 字符集
 func leptStringifyString(s string) string {
 	var buf bytes.Buffer
@@ -1116,34 +1510,54 @@ func leptStringifyString(s string) string {
 }
 
 // leptStringifyArray 考虑转义符号 unicode 字符集
-func leptStringifyArray(v *LeptValue) string {
+func leptStringifyArray(v *LeptValue, opts *LeptStringifyOptions) string {
 	var buf bytes.Buffer
 	buf.WriteByte('[')
 	n := len(v.a)
+	pretty := opts != nil && opts.Indent != "" && n > 0
 	for i := 0; i < n; i++ {
-		buf.WriteString(leptStringifyValue(v.a[i]))
+		if pretty {
+			opts.indent(&buf, leptStringifyValue(v.a[i], opts))
+		} else {
+			buf.WriteString(leptStringifyValue(v.a[i], opts))
+		}
 		if i != n-1 {
 			buf.WriteByte(',')
 		}
 	}
+	if pretty {
+		buf.WriteByte('\n')
+	}
 	buf.WriteByte(']')
 	return buf.String()
 }
 
 // leptStringifyObject 考虑转义符号 unicode 字符集
-func leptStringifyObject(v *LeptValue) string {
+func leptStringifyObject(v *LeptValue, opts *LeptStringifyOptions) string {
 	var buf bytes.Buffer
 	buf.WriteByte('{')
-	n := len(v.o)
+	members := v.o
+	if opts != nil && opts.SortKeys {
+		members = opts.sortMembers(members)
+	}
+	n := len(members)
+	pretty := opts != nil && opts.Indent != "" && n > 0
 	for i := 0; i < n; i++ {
-		key := v.o[i].key
-		value := v.o[i].value
-		buf.WriteString(leptStringifyString(key) + ":")
-		buf.WriteString(leptStringifyValue(value))
+		key := members[i].key
+		value := members[i].value
+		if pretty {
+			opts.indent(&buf, leptStringifyString(key)+": "+leptStringifyValue(value, opts))
+		} else {
+			buf.WriteString(leptStringifyString(key) + ":")
+			buf.WriteString(leptStringifyValue(value, opts))
+		}
 		if i != n-1 {
 			buf.WriteByte(',')
 		}
 	}
+	if pretty {
+		buf.WriteByte('\n')
+	}
 	buf.WriteByte('}')
 	return buf.String()
 }
@@ -1156,6 +1570,7 @@ func LeptCopy(dst, src *LeptValue) bool {
 	if dst == src {
 		panic("src == dst")
 	}
+	leptCheckMutable(dst, "LeptCopy")
 	switch src.typ {
 	case LeptNull:
 		LeptSetNull(dst)
@@ -1165,9 +1580,13 @@ func LeptCopy(dst, src *LeptValue) bool {
 		LeptSetBoolean(dst, 1)
 	case LeptNumber:
 		LeptSetNumber(dst, src.n)
+		dst.text = src.text
 	case LeptString:
 		LeptSetString(dst, src.s)
 	case LeptArray:
+		// fresh backing storage, the old content of dst must not be kept
+		LeptFree(dst)
+		dst.a = make([]*LeptValue, 0, len(src.a))
 		for i := 0; i < len(src.a); i++ {
 			ai := NewLeptValue()
 			if ok := LeptCopy(ai, src.a[i]); !ok {
@@ -1177,6 +1596,8 @@ func LeptCopy(dst, src *LeptValue) bool {
 		}
 		dst.typ = LeptArray
 	case LeptObject:
+		LeptFree(dst)
+		dst.o = make([]*LeptMember, 0, len(src.o))
 		for i := 0; i < len(src.o); i++ {
 			oi := NewLeptValue()
 			if ok := LeptCopy(oi, src.o[i].value); !ok {
@@ -1191,7 +1612,7 @@ func LeptCopy(dst, src *LeptValue) bool {
 	return true
 }
 
-// LeptMove move from src to dst
+// LeptMove move from src to dst, src is reset to null
 func LeptMove(dst, src *LeptValue) bool {
 	if dst == nil || src == nil {
 		panic("src or dst is nil")
@@ -1199,17 +1620,20 @@ func LeptMove(dst, src *LeptValue) bool {
 	if dst == src {
 		panic("src == dst")
 	}
+	leptCheckMutable(src, "LeptMove")
+	leptCheckMutable(dst, "LeptMove")
 	LeptFree(dst)
 	dst.typ = src.typ
 	dst.n = src.n
 	dst.s = src.s
 	dst.a = src.a
 	dst.o = src.o
+	dst.text = src.text
 	LeptFree(src)
 	return true
 }
 
-// LeptSwap swap lhs rhs
+// LeptSwap swap the type and content of lhs rhs
 func LeptSwap(lhs, rhs *LeptValue) bool {
 	if lhs == nil || rhs == nil {
 		panic("rhs or lhs is nil")
@@ -1217,11 +1641,14 @@ func LeptSwap(lhs, rhs *LeptValue) bool {
 	if lhs == rhs {
 		panic("rhs == lhs")
 	}
+	leptCheckMutable(lhs, "LeptSwap")
+	leptCheckMutable(rhs, "LeptSwap")
 	lhs.typ, rhs.typ = rhs.typ, lhs.typ
 	lhs.n, rhs.n = rhs.n, lhs.n
 	lhs.s, rhs.s = rhs.s, lhs.s
 	lhs.a, rhs.a = rhs.a, lhs.a
 	lhs.o, rhs.o = rhs.o, lhs.o
+	lhs.text, rhs.text = rhs.text, lhs.text
 	return true
 }
 
@@ -1277,6 +1704,142 @@ func LeptIsEqual(lhs, rhs *LeptValue) bool {
 	}
 }
 
+// LeptNormalize return a canonicalized copy of v, object members sorted by key and -0 as 0,
+// so the semantically equal documents stringify the same regardless of the source formatting
+func LeptNormalize(v *LeptValue) *LeptValue {
+	if v == nil {
+		panic("LeptNormalize v is nil")
+	}
+	ret := NewLeptValue()
+	switch v.typ {
+	case LeptNumber:
+		if v.n == 0 {
+			LeptSetNumber(ret, 0)
+		} else {
+			LeptSetNumber(ret, v.n)
+		}
+	case LeptArray:
+		ret.typ = LeptArray
+		ret.a = make([]*LeptValue, len(v.a))
+		for i, e := range v.a {
+			ret.a[i] = LeptNormalize(e)
+		}
+	case LeptObject:
+		LeptSetObject(ret)
+		for _, member := range v.o {
+			ret.o = append(ret.o, &LeptMember{key: member.key, value: LeptNormalize(member.value)})
+		}
+		sort.SliceStable(ret.o, func(i, j int) bool {
+			return ret.o[i].key < ret.o[j].key
+		})
+	default:
+		LeptCopy(ret, v)
+	}
+	return ret
+}
+
+// LeptSetArray set v to an empty array with room for capacity elements
+func LeptSetArray(v *LeptValue, capacity int) {
+	if v == nil {
+		panic("LeptSetArray v is nil")
+	}
+	if capacity < 0 {
+		panic("LeptSetArray capacity < 0")
+	}
+	leptCheckMutable(v, "LeptSetArray")
+	LeptFree(v)
+	v.a = make([]*LeptValue, 0, capacity)
+	v.typ = LeptArray
+}
+
+// LeptPushArrayElement append a null element to the array and return it to be set
+func LeptPushArrayElement(v *LeptValue) *LeptValue {
+	if v == nil || v.typ != LeptArray {
+		panic("LeptPushArrayElement v is nil or typ is not array")
+	}
+	e := NewLeptValue()
+	v.a = append(v.a, e)
+	return e
+}
+
+// LeptPopArrayElement remove the last element of the array
+func LeptPopArrayElement(v *LeptValue) {
+	if v == nil || v.typ != LeptArray {
+		panic("LeptPopArrayElement v is nil or typ is not array")
+	}
+	size := len(v.a)
+	if size == 0 {
+		panic("LeptPopArrayElement v is empty")
+	}
+	v.a[size-1] = nil
+	v.a = v.a[:size-1]
+}
+
+// LeptGetArrayCapacity use to get the number of elements the array has room for without growing
+func LeptGetArrayCapacity(v *LeptValue) int {
+	if v == nil || v.typ != LeptArray {
+		panic("LeptGetArrayCapacity v is nil or typ is not array")
+	}
+	return cap(v.a)
+}
+
+// LeptShrinkArray trim the capacity of the array to its size, the elements are kept in order
+func LeptShrinkArray(v *LeptValue) {
+	if v == nil || v.typ != LeptArray {
+		panic("LeptShrinkArray v is nil or typ is not array")
+	}
+	if cap(v.a) == len(v.a) {
+		return
+	}
+	a := make([]*LeptValue, len(v.a))
+	copy(a, v.a)
+	v.a = a
+}
+
+// LeptClearArray remove all elements of the array, the capacity is kept for reuse
+func LeptClearArray(v *LeptValue) {
+	if v == nil || v.typ != LeptArray {
+		panic("LeptClearArray v is nil or typ is not array")
+	}
+	for i := range v.a {
+		v.a[i] = nil
+	}
+	v.a = v.a[:0]
+}
+
+// LeptInsertArrayElement insert a null element at index of the array and return it to be set,
+// the elements from index on move right, index equal to the size is the same as LeptPushArrayElement
+func LeptInsertArrayElement(v *LeptValue, index int) *LeptValue {
+	if v == nil || v.typ != LeptArray {
+		panic("LeptInsertArrayElement v is nil or typ is not array")
+	}
+	if index < 0 || index > len(v.a) {
+		panic("LeptInsertArrayElement index > size || index < 0")
+	}
+	e := NewLeptValue()
+	v.a = append(v.a, nil)
+	copy(v.a[index+1:], v.a[index:])
+	v.a[index] = e
+	return e
+}
+
+// LeptEraseArrayElement remove count elements of the array from index, the rest move left,
+// a range running past the end panics rather than being clamped
+func LeptEraseArrayElement(v *LeptValue, index, count int) {
+	if v == nil || v.typ != LeptArray {
+		panic("LeptEraseArrayElement v is nil or typ is not array")
+	}
+	if index < 0 || count < 0 || index > len(v.a)-count {
+		panic("LeptEraseArrayElement index+count > size || index < 0 || count < 0")
+	}
+	size := len(v.a)
+	copy(v.a[index:], v.a[index+count:])
+	for i := size - count; i < size; i++ {
+		v.a[i] = nil
+	}
+	v.a = v.a[:size-count]
+}
+
 // LeptFindObjectIndex find index
 func LeptFindObjectIndex(v *LeptValue, key string) int {
 	if v == nil || v.typ != LeptObject {
@@ -1296,7 +1859,7 @@ func LeptFindObjectValue(v *LeptValue, key string) *LeptValue {
 	if index == LeptKeyNotExist {
 		return nil
 	}
-	return v.o[index].value
+	return leptUnshare(&v.o[index].value)
 }
 
 // LeptSetObject set object value
@@ -1304,19 +1867,31 @@ func LeptSetObject(v *LeptValue) {
 	if v == nil {
 		panic("LeptSetObject v is nil")
 	}
+	leptCheckMutable(v, "LeptSetObject")
 	LeptFree(v)
 	v.o = make([]*LeptMember, 0)
 	v.typ = LeptObject
 }
 
+// LeptClearObject remove all members of the object, the capacity is kept for reuse
+func LeptClearObject(v *LeptValue) {
+	if v == nil || v.typ != LeptObject {
+		panic("LeptClearObject v is nil or typ is not object")
+	}
+	for i := range v.o {
+		v.o[i] = nil
+	}
+	v.o = v.o[:0]
+}
+
 // LeptSetObjectValue set object value
 func LeptSetObjectValue(v *LeptValue, key string) *LeptValue {
 	if v == nil || v.typ != LeptObject {
 		panic("LeptSetObjectValue v is nil or typ is not object")
 	}
-	value := LeptFindObjectValue(v, key)
-	if value != nil {
-		return value
+	if index := LeptFindObjectIndex(v, key); index != LeptKeyNotExist {
+		// copy the interned value first, so the caller can set it
+		return leptUnshare(&v.o[index].value)
 	}
 	member := &LeptMember{key: key, value: NewLeptValue()}
 	v.o = append(v.o, member)
@@ -1338,10 +1913,110 @@ func LeptRemoveObjectValue(v *LeptValue, index int) {
 	// v.o = v.o[:size-1]
 	next := make([]*LeptMember, size-1)
 	copy(next, v.o[:index])
-	copy(next, v.o[index+1:])
+	copy(next[index:], v.o[index+1:])
 	v.o = next
 }
 
+// LeptObjectKeys return the keys of object in insertion order
+func LeptObjectKeys(v *LeptValue) []string {
+	if v == nil || v.typ != LeptObject {
+		panic("LeptObjectKeys v is nil or typ is not object")
+	}
+	keys := make([]string, len(v.o))
+	for i, member := range v.o {
+		keys[i] = member.key
+	}
+	return keys
+}
+
+// LeptObjectKeysSorted return the keys of object in ascending order
+func LeptObjectKeysSorted(v *LeptValue) []string {
+	if v == nil || v.typ != LeptObject {
+		panic("LeptObjectKeysSorted v is nil or typ is not object")
+	}
+	keys := LeptObjectKeys(v)
+	sort.Strings(keys)
+	return keys
+}
+
+// LeptValueDepth return the max nesting depth of v, 0 for scalar, 1 for flat array or object
+func LeptValueDepth(v *LeptValue) int {
+	if v == nil {
+		panic("LeptValueDepth v is nil")
+	}
+	depth := 0
+	switch v.typ {
+	case LeptArray:
+		for _, e := range v.a {
+			if d := LeptValueDepth(e); d > depth {
+				depth = d
+			}
+		}
+	case LeptObject:
+		for _, member := range v.o {
+			if d := LeptValueDepth(member.value); d > depth {
+				depth = d
+			}
+		}
+	default:
+		return 0
+	}
+	return depth + 1
+}
+
+// LeptObjectLooksLikeArray check the keys of object are exactly "0".."n-1" in any order,
+// some serializers like php produce it instead of array, empty object is not the case
+func LeptObjectLooksLikeArray(v *LeptValue) bool {
+	if v == nil || v.typ != LeptObject || len(v.o) == 0 {
+		return false
+	}
+	size := len(v.o)
+	seen := make([]bool, size)
+	for i := 0; i < size; i++ {
+		index, ok := leptArrayIndex(v.o[i].key)
+		if !ok || index >= size || seen[index] {
+			return false
+		}
+		seen[index] = true
+	}
+	return true
+}
+
+// LeptObjectToArray convert the object which looks like array to a new array,
+// return nil if the object does not look like array
+func LeptObjectToArray(v *LeptValue) *LeptValue {
+	if !LeptObjectLooksLikeArray(v) {
+		return nil
+	}
+	a := NewLeptValue()
+	a.typ = LeptArray
+	a.a = make([]*LeptValue, len(v.o))
+	for i := 0; i < len(v.o); i++ {
+		index, _ := leptArrayIndex(v.o[i].key)
+		e := NewLeptValue()
+		LeptCopy(e, v.o[i].value)
+		a.a[index] = e
+	}
+	return a
+}
+
+// leptArrayIndex parse key as a array index, "0" or digit1-9 *digit
+func leptArrayIndex(key string) (int, bool) {
+	if len(key) == 0 || (key[0] == '0' && len(key) > 1) {
+		return 0, false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isDigit(key[i]) {
+			return 0, false
+		}
+	}
+	index, err := strconv.Atoi(key)
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
 // ToInterface transfer the LeptValue to golang interface{}
 func ToInterface(v *LeptValue) interface{} {
 	if v == nil {
@@ -1367,6 +2042,95 @@ func ToInterface(v *LeptValue) interface{} {
 	}
 }
 
+// LeptToInterface transfer the LeptValue to nil, bool, float64, string, []interface{} or map[string]interface{},
+// the same shape as json.Unmarshal into interface{}, a duplicate key keeps the last value like it
+func LeptToInterface(v *LeptValue) interface{} {
+	if v == nil {
+		panic("LeptToInterface v is nil")
+	}
+	return ToInterface(v)
+}
+
+// LeptFromInterface build v from nil, bool, float64, float32, the integers, string,
+// []interface{} or map[string]interface{} nested in any depth, the members of map are sorted by key,
+// an unsupported type or a number which is NaN or infinity fails with an error and leaves v untouched
+func LeptFromInterface(v *LeptValue, data interface{}) error {
+	if v == nil {
+		panic("LeptFromInterface v is nil")
+	}
+	leptCheckMutable(v, "LeptFromInterface")
+	built := NewLeptValue()
+	if err := leptFromInterface(built, data); err != nil {
+		return err
+	}
+	LeptMove(v, built)
+	return nil
+}
+
+func leptFromInterface(v *LeptValue, data interface{}) error {
+	switch d := data.(type) {
+	case nil:
+		LeptSetNull(v)
+	case bool:
+		if d {
+			LeptSetBoolean(v, 1)
+		} else {
+			LeptSetBoolean(v, 0)
+		}
+	case float64:
+		if math.IsNaN(d) || math.IsInf(d, 0) {
+			return fmt.Errorf("goleptjson: LeptFromInterface unsupported number %v", d)
+		}
+		LeptSetNumber(v, d)
+	case float32:
+		return leptFromInterface(v, float64(d))
+	case int:
+		LeptSetNumber(v, float64(d))
+	case int8:
+		LeptSetNumber(v, float64(d))
+	case int16:
+		LeptSetNumber(v, float64(d))
+	case int32:
+		LeptSetNumber(v, float64(d))
+	case int64:
+		LeptSetNumber(v, float64(d))
+	case uint:
+		LeptSetNumber(v, float64(d))
+	case uint8:
+		LeptSetNumber(v, float64(d))
+	case uint16:
+		LeptSetNumber(v, float64(d))
+	case uint32:
+		LeptSetNumber(v, float64(d))
+	case uint64:
+		LeptSetNumber(v, float64(d))
+	case string:
+		LeptSetString(v, d)
+	case []interface{}:
+		LeptSetArray(v, len(d))
+		for _, e := range d {
+			if err := leptFromInterface(LeptPushArrayElement(v), e); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(d))
+		for key := range d {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		LeptSetObject(v)
+		for _, key := range keys {
+			if err := leptFromInterface(LeptSetObjectValue(v, key), d[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("goleptjson: LeptFromInterface unsupported type %T", data)
+	}
+	return nil
+}
+
 // ToMap transafer the LeptValue to a golang map[string]interface
 func ToMap(v *LeptValue) map[string]interface{} {
 	if v == nil || v.typ != LeptObject {
@@ -1493,7 +2257,7 @@ func toValue(v *LeptValue, rv reflect.Value) error {
 	switch rv.Kind() {
 	case reflect.Ptr:
 		// 对应的 v 为 LeptNull 时， decodingNull = true
-		fmt.Println("toValue got reflect.Ptr of v ", v)
+		rv.Set(reflect.Zero(rv.Type()))
 	case reflect.Interface:
 		// 可能对应的 rv 为 []interface{} interface{}
 		// fmt.Println("toValue got reflect.Interface of v ", v, rv)
@@ -1605,15 +2369,20 @@ func toStruct(v *LeptValue, rv reflect.Value) error {
 	for i := 0; i < size; i++ {
 		fit := rt.Field(i)
 		// fmt.Println(fit.Tag)
+		if fit.PkgPath != "" {
+			// unexported
+			continue
+		}
 		tag := fit.Tag.Get("json")
 		if tag == "-" {
 			continue
 		}
-		name, opts := parseTag(tag)
-		fiName := name
 		// 只有 encode 的时候， omitempty 是起作用的
-		if strings.Index(opts, "omitempty") != -1 {
-			fmt.Println(tag, opts)
+		name, _ := parseTag(tag)
+		fiName := name
+		if fiName == "" {
+			// untagged field use the field name like encoding/json
+			fiName = fit.Name
 		}
 		// fmt.Println()
 		// fmt.Println(fit.Tag.Get("omitempty"))
@@ -1626,6 +2395,10 @@ func toStruct(v *LeptValue, rv reflect.Value) error {
 			return fmt.Errorf("v LeptValue is not a object: %v", v.typ)
 		} else {
 			liv := LeptFindObjectValue(v, fiName)
+			if liv == nil && name == "" {
+				// the field name matches the key case-insensitively like encoding/json
+				liv = leptFindObjectValueFold(v, fiName)
+			}
 			if err := toValue(liv, rv.Field(i)); err != nil {
 				return err
 			}
@@ -1633,6 +2406,16 @@ func toStruct(v *LeptValue, rv reflect.Value) error {
 	}
 	return nil
 }
+
+// leptFindObjectValueFold find the first member whose key equals key under Unicode case-folding
+func leptFindObjectValueFold(v *LeptValue, key string) *LeptValue {
+	for _, member := range v.o {
+		if strings.EqualFold(member.key, key) {
+			return member.value
+		}
+	}
+	return nil
+}
 func toMap(v *LeptValue, rv reflect.Value) error {
 	if !rv.IsValid() {
 		return fmt.Errorf("v is not valid")
@@ -1762,12 +2545,11 @@ func setValue(rv reflect.Value) {
 	rv.SetBool(true)
 }
 
-// Unmarshal parse input data into structure
+// Unmarshal parse input data into structure, the parse failure is reported as *LeptError
 func Unmarshal(data []byte, structure interface{}) error {
 	v := NewLeptValue()
-	event := LeptParse(v, string(data))
-	if event != LeptParseOK {
-		return fmt.Errorf("Unmarshal parse error: %v", event)
+	if err := LeptParseErr(v, string(data)); err != nil {
+		return err
 	}
 	return ToStruct(v, structure)
 }
@@ -1782,21 +2564,17 @@ var (
 	marshalerType = reflect.TypeOf(new(Marshaler)).Elem()
 )
 
-type encodeState struct {
-	bytes.Buffer
-}
-
-// Marshal stringify the input structure
+// Marshal stringify the input structure, it builds the LeptValue tree of structure and writes it by LeptStringify
 func Marshal(structure interface{}) ([]byte, error) {
-	e := &encodeState{}
-	err := e.marshal(structure)
-	if err != nil {
+	v := NewLeptValue()
+	if err := marshal(v, structure); err != nil {
 		return nil, err
 	}
-	return e.Bytes(), nil
+	return []byte(LeptStringify(v)), nil
 }
 
-func (e *encodeState) marshal(structure interface{}) (err error) {
+// marshal set v to the value of structure, the failure is panicked as error and returned here
+func marshal(v *LeptValue, structure interface{}) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if _, ok := r.(runtime.Error); ok {
@@ -1808,7 +2586,7 @@ func (e *encodeState) marshal(structure interface{}) (err error) {
 			err = r.(error)
 		}
 	}()
-	e.reflectValue(reflect.ValueOf(structure), true)
+	reflectValue(v, reflect.ValueOf(structure), true)
 	return nil
 }
 func isEmptyValue(v reflect.Value) bool {
@@ -1828,22 +2606,23 @@ func isEmptyValue(v reflect.Value) bool {
 	}
 	return false
 }
-func (e *encodeState) reflectValue(v reflect.Value, allowAddr bool) {
+
+// reflectValue set lv to the value of v, lv is null on entry
+func reflectValue(lv *LeptValue, v reflect.Value, allowAddr bool) {
 	if !v.IsValid() {
-		e.WriteString("null")
 		return
 	}
 	t := v.Type()
 	if t.Implements(marshalerType) {
-		marshalerEncoder(e, v, false)
+		marshalerValue(lv, v)
 		return
 	}
 	if t.Kind() != reflect.Ptr && allowAddr {
 		if reflect.PtrTo(t).Implements(marshalerType) {
 			if v.CanAddr() {
-				addrMarshalerEncoder(e, v, false)
+				marshalerValue(lv, v.Addr())
 			} else {
-				e.reflectValue(v, false)
+				reflectValue(lv, v, false)
 			}
 			return
 		}
@@ -1852,33 +2631,35 @@ func (e *encodeState) reflectValue(v reflect.Value, allowAddr bool) {
 	switch t.Kind() {
 	case reflect.Bool:
 		if v.Bool() {
-			e.WriteString("true")
+			LeptSetBoolean(lv, 1)
 		} else {
-			e.WriteString("false")
+			LeptSetBoolean(lv, 0)
 		}
+	// the text keeps the integers above 2^53 and float32 exact
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		b := strconv.AppendInt([]byte(""), v.Int(), 10)
-		e.Write(b)
+		LeptSetNumber(lv, float64(v.Int()))
+		lv.text = strconv.FormatInt(v.Int(), 10)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		b := strconv.AppendUint([]byte(""), v.Uint(), 10)
-		e.Write(b)
-	case reflect.Float32:
-		b := strconv.AppendFloat([]byte(""), v.Float(), 'g', -1, 32)
-		e.Write(b)
-	case reflect.Float64:
-		b := strconv.AppendFloat([]byte(""), v.Float(), 'g', -1, 64)
-		e.Write(b)
+		LeptSetNumber(lv, float64(v.Uint()))
+		lv.text = strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			panic(fmt.Errorf("goleptjson: marshal unsupported value %v", f))
+		}
+		LeptSetNumber(lv, f)
+		if t.Kind() == reflect.Float32 {
+			lv.text = strconv.FormatFloat(f, 'g', -1, 32)
+		}
 	case reflect.String:
-		e.WriteString(leptStringifyString(v.String()))
+		LeptSetString(lv, v.String())
 	case reflect.Interface:
 		if v.IsNil() {
-			e.WriteString("null")
 			return
 		}
-		e.reflectValue(v.Elem(), false)
+		reflectValue(lv, v.Elem(), false)
 	case reflect.Struct:
-		e.WriteByte('{')
-		first := true
+		LeptSetObject(lv)
 		size := v.NumField()
 		rt := t
 		for i := 0; i < size; i++ {
@@ -1893,72 +2674,64 @@ func (e *encodeState) reflectValue(v reflect.Value, allowAddr bool) {
 				continue
 			}
 			name, opts := parseTag(tag)
+			if name == "" {
+				// untagged field use the field name like encoding/json
+				name = fit.Name
+			}
 			// 只有 encode 的时候， omitempty 是起作用的
 			fi := v.Field(i)
 			if !fi.IsValid() || strings.Index(opts, "omitempty") != -1 && isEmptyValue(fi) {
 				continue
 			}
-			if first {
-				first = false
-			} else {
-				e.WriteByte(',')
-			}
-			e.WriteString(leptStringifyString(name))
-			e.WriteByte(':')
-			e.reflectValue(fi, true)
+			member := &LeptMember{key: name, value: NewLeptValue()}
+			lv.o = append(lv.o, member)
+			reflectValue(member.value, fi, true)
 		}
-		e.WriteByte('}')
 	case reflect.Map:
 		if v.IsNil() {
-			e.WriteString("null")
 			return
 		}
-		e.WriteByte('{')
+		LeptSetObject(lv)
 		sv := v.MapKeys()
 		sort.Slice(sv, func(i, j int) bool {
 			return sv[i].String() < sv[j].String()
 		})
-		for i, k := range sv {
-			if i > 0 {
-				e.WriteByte(',')
-			}
-			e.WriteString(leptStringifyString(k.String()))
-			e.WriteByte(':')
-			// me.elemEnc(e, v.MapIndex(k), false)
-			e.reflectValue(v.MapIndex(k), false)
+		for _, k := range sv {
+			member := &LeptMember{key: k.String(), value: NewLeptValue()}
+			lv.o = append(lv.o, member)
+			reflectValue(member.value, v.MapIndex(k), false)
 		}
-		e.WriteByte('}')
 	case reflect.Slice:
 		if v.IsNil() {
-			e.WriteString("null")
 			return
 		}
 		fallthrough
 	case reflect.Array:
-		e.WriteByte('[')
 		n := v.Len()
+		LeptSetArray(lv, n)
 		for i := 0; i < n; i++ {
-			if i > 0 {
-				e.WriteByte(',')
-			}
-			e.reflectValue(v.Index(i), false)
+			reflectValue(LeptPushArrayElement(lv), v.Index(i), false)
 		}
-		e.WriteByte(']')
 	case reflect.Ptr:
 		if v.IsNil() {
-			e.WriteString("null")
 			return
 		}
-		e.reflectValue(v.Elem(), false)
+		reflectValue(lv, v.Elem(), false)
 	default:
-		fmt.Println(v, t, t.Kind())
-		panic("marshal unsupport type")
+		// the error is returned by marshal
+		panic(fmt.Errorf("goleptjson: marshal unsupported type %v", t))
 	}
 }
 
-func marshalerEncoder(e *encodeState, v reflect.Value, quoted bool) {
+// leptMarshalerParseOptions keep the numbers of MarshalJSON as written
+var leptMarshalerParseOptions = LeptParseOptions{
+	MaxDepth:       LeptDefaultMaxDepth,
+	KeepNumberText: true,
+}
+
+// marshalerValue set lv to the json written by MarshalJSON of v
+func marshalerValue(lv *LeptValue, v reflect.Value) {
 	if v.Kind() == reflect.Ptr && v.IsNil() {
-		e.WriteString("null")
 		return
 	}
 	m := v.Interface().(Marshaler)
@@ -1966,19 +2739,7 @@ func marshalerEncoder(e *encodeState, v reflect.Value, quoted bool) {
 	if err != nil {
 		panic(err)
 	}
-	e.Write(b)
-}
-
-func addrMarshalerEncoder(e *encodeState, v reflect.Value, quoted bool) {
-	va := v.Addr()
-	if va.IsNil() {
-		e.WriteString("null")
-		return
-	}
-	m := va.Interface().(Marshaler)
-	b, err := m.MarshalJSON()
-	if err != nil {
-		panic(err)
+	if err := LeptParseErrWithOptions(lv, string(b), &leptMarshalerParseOptions); err != nil {
+		panic(fmt.Errorf("goleptjson: MarshalJSON of %v: %w", v.Type(), err))
 	}
-	e.Write(b)
 }
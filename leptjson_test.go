@@ -3,8 +3,11 @@ package goleptjson
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -38,6 +41,14 @@ func expectEQLeptType(t *testing.T, expect, actual LeptType) {
 		t.Errorf("parse types, expect: %v, actual: %v", expect, actual)
 	}
 }
+func expectPanic(t *testing.T, name string, f func()) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("%v should panic", name)
+		}
+	}()
+	f()
+}
 func TestLeptParseNull(t *testing.T) {
 	v := NewLeptValue()
 	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "null"))
@@ -53,6 +64,44 @@ func TestLeptParseFalse(t *testing.T) {
 	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "false"))
 	expectEQLeptType(t, LeptFalse, LeptGetType(v))
 }
+func TestLeptParseZero(t *testing.T) {
+	// a leading 0 is the whole integer part, the fraction and exponent may still follow it
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"0e0", "0"},
+		{"0E5", "0"},
+		{"0E+10", "0"},
+		{"0e-10", "0"},
+		{"-0e0", "-0"},
+		{"-0E+10", "-0"},
+		{"0.0e0", "0"},
+		{"0.000E-3", "0"},
+		{"[0e0,0E1]", "[0,0]"},
+		{"{\"a\":0e0}", "{\"a\":0}"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQString(t, c.expect, LeptStringify(v))
+		expectEQLeptEvent(t, LeptParseOK, LeptValidate(c.input))
+	}
+	for _, input := range []string{"0e0", "0E5", "0E+10"} {
+		if ret, rest, err := strtod(input); err != nil || ret != 0 || rest != "" {
+			t.Errorf("strtod %q err: %v, ret: %v, rest: %q", input, err, ret, rest)
+		}
+	}
+	// the integer part is never more than one 0
+	invalid := []string{"00", "-00", "01", "00e1", "00.5", "0e", "0E+", "0.e1", "[00]"}
+	for _, input := range invalid {
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptParse(NewLeptValue(), input))
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptValidate(input))
+		if ret, _, err := strtod(input); err == nil {
+			t.Errorf("strtod %q should have err, ret: %v", input, ret)
+		}
+	}
+}
 func TestLeptParseNumber(t *testing.T) {
 	valid := []struct {
 		input  string
@@ -134,6 +183,30 @@ func TestLeptParseNumber(t *testing.T) {
 	}
 	// TEST_ERROR(LEPT_PARSE_NUMBER_TOO_BIG, "1e309");
 	// TEST_ERROR(LEPT_PARSE_NUMBER_TOO_BIG, "-1e309");
+	for _, input := range []string{"1e309", "-1e309", "1.8e308", "[1e400]", "1" + strings.Repeat("0", 309)} {
+		expectEQLeptEvent(t, LeptParseNumberTooBig, LeptParse(NewLeptValue(), input))
+	}
+	// underflow to zero or denormal is not an error
+	tiny := []struct {
+		input  string
+		expect float64
+	}{
+		{"1e-400", 0},
+		{"-1e-400", math.Copysign(0, -1)},
+		{"1e-10000", 0},
+		{"2e-324", 0},
+		{"3e-324", 5e-324},
+		{"4.9406564584124654e-324", 5e-324},
+		{"2.2250738585072009e-308", 2.2250738585072009e-308},
+		{"0." + strings.Repeat("0", 400) + "1", 0},
+	}
+	for _, c := range tiny {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		if math.Float64bits(c.expect) != math.Float64bits(LeptGetNumber(v)) {
+			t.Errorf("parse %v, expect: %v, actual: %v", c.input, c.expect, LeptGetNumber(v))
+		}
+	}
 }
 func TestParseFloat(t *testing.T) {
 	valid := []struct {
@@ -167,6 +240,9 @@ func TestParseFloat(t *testing.T) {
 		if ret, _, err := strToFloat64(c.input); err != nil || ret != c.expect {
 			t.Errorf("strToFloat64 err:  %v, ret: %v, expect: %v", err, ret, c.expect)
 		}
+		if ret, _, err := strtod(c.input); err != nil || ret != c.expect {
+			t.Errorf("strtod err:  %v, ret: %v, expect: %v", err, ret, c.expect)
+		}
 	}
 	edges := []struct {
 		input  string
@@ -191,6 +267,9 @@ func TestParseFloat(t *testing.T) {
 		if ret, _, err := strToFloat64(c.input); err != nil || ret != c.expect {
 			t.Errorf("strToFloat64 err:  %v, ret: %v, expect: %v", err, ret, c.expect)
 		}
+		if ret, _, err := strtod(c.input); err != nil || ret != c.expect {
+			t.Errorf("strtod err:  %v, ret: %v, expect: %v", err, ret, c.expect)
+		}
 	}
 	invalid := []struct {
 		input  string
@@ -220,6 +299,50 @@ func TestParseFloat(t *testing.T) {
 			t.Errorf("strToFloat64 should have err:  %v, ret: %v, expect: %v", err, ret, c.expect)
 		}
 	}
+	// strtod check the grammar before strconv.ParseFloat
+	for _, input := range []string{"", "-", ".5", "-.5", "0123", "-0123", "1.", "1e", "1e+", "+1", "0x10"} {
+		if ret, _, err := strtod(input); err == nil {
+			t.Errorf("strtod %q should have err, ret: %v", input, ret)
+		}
+	}
+	rests := []struct {
+		input  string
+		expect float64
+		rest   string
+	}{
+		{"0.0001", 0.0001, ""},
+		{"0.1e-3", 0.0001, ""},
+		{"123.456e2,", 12345.6, ","},
+		{"-0.5]", -0.5, "]"},
+		{"0 ", 0, " "},
+		{"1.25e1}", 12.5, "}"},
+	}
+	for _, c := range rests {
+		ret, rest, err := strtod(c.input)
+		if err != nil || ret != c.expect || rest != c.rest {
+			t.Errorf("strtod %q err: %v, ret: %v, rest: %q", c.input, err, ret, rest)
+		}
+	}
+	// the integer part does not depend on the width of int
+	longs := []struct {
+		input  string
+		expect float64
+	}{
+		{"12345678901234567890", 12345678901234567890},
+		{"-12345678901234567890", -12345678901234567890},
+		{"123456789012345678901234567890", 123456789012345678901234567890},
+		{"9223372036854775808", 9223372036854775808},
+		{"18446744073709551616.5", 18446744073709551616.5},
+		{"1" + strings.Repeat("0", 308), 1e308},
+	}
+	for _, c := range longs {
+		if ret, _, err := strtod(c.input); err != nil || ret != c.expect {
+			t.Errorf("strtod %q err: %v, ret: %v, expect: %v", c.input, err, ret, c.expect)
+		}
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQFloat64(t, c.expect, LeptGetNumber(v))
+	}
 }
 
 func TestParseExpectValue(t *testing.T) {
@@ -239,13 +362,312 @@ func TestParseInvalidValue(t *testing.T) {
 
 	expectEQLeptEvent(t, LeptParseInvalidValue, LeptParse(v, "?"))
 	expectEQLeptType(t, LeptNull, LeptGetType(v))
+
+	// no plus sign, even with a custom NumberParser which would take it
+	opts := NewLeptParseOptions()
+	opts.NumberParser = func(literal string) (float64, error) { return strconv.ParseFloat(literal, 64) }
+	for _, input := range []string{"+1", "+0", "++1", "+1.5e3", "-+1", "[+1]", "{\"a\":+0}"} {
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptParse(v, input))
+		expectEQLeptType(t, LeptNull, LeptGetType(v))
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptParseWithOptions(v, input, opts))
+	}
+	for _, input := range []string{"+1", "+0", "++1"} {
+		if ret, rest, err := strtod(input); err == nil || rest != input {
+			t.Errorf("strtod %q should have err, ret: %v, rest: %q", input, ret, rest)
+		}
+	}
 }
+func TestParseStrayPunctuation(t *testing.T) {
+	// a value never starts with punctuation, it fails cleanly wherever a value is expected
+	for _, ch := range ",:)(;'`=*&%$#@!?<>|\\~^_." {
+		for _, input := range []string{string(ch), " " + string(ch) + " ", "[" + string(ch) + "]", "{\"a\":" + string(ch) + "}", "[1," + string(ch)} {
+			v := NewLeptValue()
+			expectEQLeptEvent(t, LeptParseInvalidValue, LeptParse(v, input))
+			expectEQLeptType(t, LeptNull, LeptGetType(v))
+			expectEQLeptEvent(t, LeptParseInvalidValue, LeptValidate(input))
+		}
+	}
+	for _, input := range []string{"]", "}", " ] ", "[1,]", "[}]", "{\"a\":]}", "{\"a\":}"} {
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptParse(NewLeptValue(), input))
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptValidate(input))
+	}
+	// the number parser alone is safe at the end of input
+	expectEQLeptEvent(t, LeptParseInvalidValue, LeptParseNumber(NewLeptContext(""), NewLeptValue()))
+	expectEQLeptEvent(t, LeptParseInvalidValue, LeptParseNumber(NewLeptContext("-"), NewLeptValue()))
+	expectEQLeptEvent(t, LeptParseExpectValue, LeptParseValue(NewLeptContext(""), NewLeptValue()))
+}
+func TestLeptParseConsumed(t *testing.T) {
+	valid := []struct {
+		input    string
+		expect   string
+		consumed int
+	}{
+		{"  42  xyz", "42", 4},
+		{"42", "42", 2},
+		{"null,", "null", 4},
+		{"\t\"a b\" \"c\"", "\"a b\"", 6},
+		{"[1, 2] [3]", "[1,2]", 6},
+		{"{\"a\":{}}\n{\"b\":1}\n", "{\"a\":{}}", 8},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		event, consumed := LeptParseConsumed(v, c.input)
+		expectEQLeptEvent(t, LeptParseOK, event)
+		expectEQInt(t, c.consumed, consumed)
+		expectEQString(t, c.expect, LeptStringify(v))
+	}
+	// split a stream by hand
+	input := "1 [2] {\"3\":3}  "
+	var values []string
+	for {
+		rest := strings.TrimLeft(input, " ")
+		if rest == "" {
+			break
+		}
+		v := NewLeptValue()
+		event, consumed := LeptParseConsumed(v, rest)
+		expectEQLeptEvent(t, LeptParseOK, event)
+		values = append(values, LeptStringify(v))
+		input = rest[consumed:]
+	}
+	expectEQString(t, "1 [2] {\"3\":3}", strings.Join(values, " "))
+	// the old value is dropped when v is reused
+	v := NewLeptValue()
+	LeptParseConsumed(v, "[1,2] x")
+	event, consumed := LeptParseConsumed(v, "[3] x")
+	expectEQLeptEvent(t, LeptParseOK, event)
+	expectEQInt(t, 3, consumed)
+	expectEQString(t, "[3]", LeptStringify(v))
+
+	invalid := []struct {
+		input    string
+		expect   LeptEvent
+		consumed int
+	}{
+		{"", LeptParseExpectValue, 0},
+		{"   ", LeptParseExpectValue, 3},
+		{" [1 x", LeptParseMissCommaOrSouareBracket, 4},
+		{" tru", LeptParseInvalidValue, 1},
+	}
+	for _, c := range invalid {
+		event, consumed := LeptParseConsumed(NewLeptValue(), c.input)
+		expectEQLeptEvent(t, c.expect, event)
+		expectEQInt(t, c.consumed, consumed)
+	}
+	expectPanic(t, "LeptParseConsumed", func() { LeptParseConsumed(nil, "1") })
+}
+
 func TestParseRootNotSingular(t *testing.T) {
 	v := NewLeptValue()
 
 	expectEQLeptEvent(t, LeptParseRootNotSingular, LeptParse(v, "null x"))
 	expectEQLeptType(t, LeptNull, LeptGetType(v))
 }
+func TestLeptGetErrorPosition(t *testing.T) {
+	valid := []struct {
+		input string
+		event LeptEvent
+		line  int
+		col   int
+	}{
+		{"nul", LeptParseInvalidValue, 1, 1},
+		{"[1, tru]", LeptParseInvalidValue, 1, 5},
+		{"{\n  \"a\": 1,\n  \"b\": x\n}", LeptParseInvalidValue, 3, 8},
+		{"[\r\n\t1\r\n\t2]", LeptParseMissCommaOrSouareBracket, 3, 2},
+		{"[1,\r2,\rx]", LeptParseInvalidValue, 3, 1},
+		{"[1,\r\r\n\n2,x]", LeptParseInvalidValue, 4, 3},
+		{"\n\n\"a\nb\"", LeptParseInvalidStringChar, 3, 2},
+		{"{}\n\n x", LeptParseRootNotSingular, 3, 2},
+		{"[1,\n2]\n", LeptParseOK, 3, 1},
+	}
+	for _, c := range valid {
+		ctx := NewLeptContext(c.input)
+		expectEQLeptEvent(t, c.event, leptParse(ctx, NewLeptValue()))
+		line, col := LeptGetErrorPosition(ctx)
+		expectEQInt(t, c.line, line)
+		expectEQInt(t, c.col, col)
+	}
+	line, col := LeptGetErrorPosition(NewLeptContext("[]"))
+	expectEQInt(t, 1, line)
+	expectEQInt(t, 1, col)
+}
+
+func TestLeptParseErr(t *testing.T) {
+	valid := []struct {
+		input  string
+		event  LeptEvent
+		offset int
+		expect string
+	}{
+		{"[1, 2, x]", LeptParseInvalidValue, 7, "goleptjson: invalid value at offset 7"},
+		{"", LeptParseExpectValue, 0, "goleptjson: expect value at offset 0"},
+		{"null x", LeptParseRootNotSingular, 5, "goleptjson: root not singular at offset 5"},
+		{"{\"a\" 1}", LeptParseMissColon, 5, "goleptjson: miss colon at offset 5"},
+	}
+	for _, c := range valid {
+		err := LeptParseErr(NewLeptValue(), c.input)
+		e, ok := err.(*LeptError)
+		if !ok {
+			t.Errorf("LeptParseErr %q expect *LeptError, actual: %v", c.input, err)
+			continue
+		}
+		expectEQLeptEvent(t, c.event, e.Event)
+		expectEQInt(t, c.offset, e.Offset)
+		expectEQString(t, c.expect, err.Error())
+	}
+	v := NewLeptValue()
+	if err := LeptParseErr(v, "[1, 2]"); err != nil {
+		t.Errorf("LeptParseErr expect no err: %v", err)
+	}
+	expectEQInt(t, 2, LeptGetArraySize(v))
+	// every event has a message
+	expectEQInt(t, len(eventNames), len(eventMessages))
+}
+
+func TestLeptEnumStrings(t *testing.T) {
+	types := []struct {
+		typ    LeptType
+		expect string
+	}{
+		{LeptNull, "LeptNull"},
+		{LeptFalse, "LeptFalse"},
+		{LeptTrue, "LeptTrue"},
+		{LeptNumber, "LeptNumber"},
+		{LeptString, "LeptString"},
+		{LeptArray, "LeptArray"},
+		{LeptObject, "LeptObject"},
+	}
+	expectEQInt(t, len(typeNames), len(types))
+	for _, c := range types {
+		expectEQString(t, c.expect, c.typ.String())
+		expectEQString(t, c.expect, fmt.Sprintf("%v", c.typ))
+	}
+	expectEQString(t, "LeptUnkown", LeptType(len(types)).String())
+	expectEQString(t, "LeptUnkown", LeptType(-1).String())
+
+	events := []struct {
+		event   LeptEvent
+		name    string
+		message string
+	}{
+		{LeptParseOK, "LeptParseOK", "ok"},
+		{LeptParseExpectValue, "LeptParseExpectValue", "expect value"},
+		{LeptParseInvalidValue, "LeptParseInvalidValue", "invalid value"},
+		{LeptParseRootNotSingular, "LeptParseRootNotSingular", "root not singular"},
+		{LeptParseNumberTooBig, "LeptParseNumberTooBig", "number too big"},
+		{LeptParseMissQuotationMark, "LeptParseMissQuotationMark", "miss quotation mark"},
+		{LeptParseInvalidStringEscape, "LeptParseInvalidStringEscape", "invalid string escape"},
+		{LeptParseInvalidStringChar, "LeptParseInvalidStringChar", "invalid string char"},
+		{LeptParseInvalidUnicodeHex, "LeptParseInvalidUnicodeHex", "invalid unicode hex"},
+		{LeptParseInvalidUnicodeSurrogate, "LeptParseInvalidUnicodeSurrogate", "invalid unicode surrogate"},
+		{LeptParseMissCommaOrSquareBracket, "LeptParseMissCommaOrSouareBracket", "miss comma or square bracket"},
+		{LeptParseMissKey, "LeptParseMissKey", "miss key"},
+		{LeptParseMissColon, "LeptParseMissColon", "miss colon"},
+		{LeptParseMissCommaOrCurlyBracket, "LeptParseMissCommaOrCurlyBracket", "miss comma or curly bracket"},
+		{LeptParseAstralChar, "LeptParseAstralChar", "astral char"},
+		{LeptParseInvalidUTF8, "LeptParseInvalidUTF8", "invalid utf8"},
+		{LeptParseExponentTooLarge, "LeptParseExponentTooLarge", "exponent too large"},
+		{LeptParseDuplicateKey, "LeptParseDuplicateKey", "duplicate key"},
+		{LeptParseMaxDepth, "LeptParseMaxDepth", "max depth exceeded"},
+	}
+	expectEQInt(t, len(eventNames), len(events))
+	for _, c := range events {
+		expectEQString(t, c.name, c.event.String())
+		expectEQString(t, c.message, LeptParseResultString(c.event))
+	}
+	expectEQString(t, "LeptParseError", LeptEvent(len(events)).String())
+	expectEQString(t, "parse error", LeptParseResultString(LeptEvent(len(events))))
+	expectEQString(t, "LeptParseError", LeptEvent(-1).String())
+	expectEQString(t, "parse error", LeptParseResultString(-1))
+}
+
+func TestLeptErrorSnippet(t *testing.T) {
+	input := "{\n  \"a\": 1,\n  \"b\": [1, 2],\n  \"c\": tru,\n  \"d\": 4,\n  \"e\": 5,\n  \"f\": 6\n}"
+	err := LeptParseErrWithOptions(NewLeptValue(), input, nil)
+	e, ok := err.(*LeptError)
+	if !ok {
+		t.Errorf("LeptParseErrWithOptions expect *LeptError, actual: %v", err)
+		return
+	}
+	expectEQLeptEvent(t, LeptParseInvalidValue, e.Event)
+	expect := "2 |   \"a\": 1,\n" +
+		"3 |   \"b\": [1, 2],\n" +
+		"4 |   \"c\": tru,\n" +
+		"  |        ^\n" +
+		"5 |   \"d\": 4,\n" +
+		"6 |   \"e\": 5,\n"
+	expectEQString(t, expect, LeptErrorSnippet(input, e.Offset))
+
+	valid := []struct {
+		input  string
+		offset int
+		expect string
+	}{
+		{"", 0, "1 | \n  | ^\n"},
+		{"tru", 0, "1 | tru\n  | ^\n"},
+		{"[1,", 3, "1 | [1,\n  |    ^\n"},
+		{"[1,", 100, "1 | [1,\n  |    ^\n"},
+		{"[\r\n\t1 x\r\n]", 6, "1 | [\n2 | \t1 x\n  | \t  ^\n3 | ]\n"},
+		// a lone \r is a line break and \r\n is one
+		{"[1,\r2,\rx]", 7, "1 | [1,\n2 | 2,\n3 | x]\n  | ^\n"},
+		{"[1,\r\r\n\n2,x]", 9, "2 | \n3 | \n4 | 2,x]\n  |   ^\n"},
+		{"[1,\r\n2]", 4, "1 | [1,\n  |    ^\n2 | 2]\n"},
+		{"[\"\xE4\xB8\xAD\" x]", 6, "1 | [\"\xE4\xB8\xAD\" x]\n  |     ^\n"},
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11", 19, " 8 | 8\n 9 | 9\n10 | 10\n   |  ^\n11 | 11\n"},
+	}
+	for _, c := range valid {
+		expectEQString(t, c.expect, LeptErrorSnippet(c.input, c.offset))
+	}
+}
+func TestParseLineEndings(t *testing.T) {
+	lines := []string{
+		"{",
+		"\t\"a\" : [ 1,",
+		"\t\t2 ],",
+		"",
+		"\t\"s\" : \"x\"",
+		"}",
+	}
+	expect := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(expect, strings.Join(lines, "\n")))
+	for _, eol := range []string{"\r\n", "\r", "\n\r", "\r\n\r\n"} {
+		v := NewLeptValue()
+		input := eol + strings.Join(lines, eol) + eol
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+		expectEQBool(t, true, LeptIsEqual(expect, v))
+	}
+	// line ending inside a string is still an invalid char
+	for _, input := range []string{"\"a\r\nb\"", "\"a\rb\""} {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseInvalidStringChar, LeptParse(v, input))
+	}
+}
+func TestLeptGetStringRuneLength(t *testing.T) {
+	valid := []struct {
+		input  string
+		bytes  int
+		expect int
+	}{
+		{"\"\"", 0, 0},
+		{"\"abc\"", 3, 3},
+		{"\"\u00e9t\u00e9\"", 5, 3},
+		{"\"\xE4\xB8\xAD\xE6\x96\x87\"", 6, 2},
+		{"\"\\uD83D\\uDE00!\"", 5, 2},
+		{"\"a\xFFb\"", 3, 3},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQInt(t, c.bytes, LeptGetStringLength(v))
+		expectEQInt(t, c.expect, LeptGetStringRuneLength(v))
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("LeptGetStringRuneLength should panic for non string")
+		}
+	}()
+	LeptGetStringRuneLength(NewLeptValue())
+}
 func TestParseString(t *testing.T) {
 	valid := []struct {
 		input  string
@@ -271,6 +693,32 @@ func TestParseString(t *testing.T) {
 		expectEQString(t, c.expect, LeptGetString(v))
 	}
 }
+func TestParseStringScratch(t *testing.T) {
+	// the scratch buffer is shared by all strings of a document, no string sees another
+	v := NewLeptValue()
+	input := "{\"a long key \\u00e9\":\"x\",\"k\":[\"\",\"a much longer value\\n\",\"y\"],\"\":{\"\\\"\":\"\\t\"}}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	expectEQString(t, "a long key \u00e9", LeptGetObjectKey(v, 0))
+	expectEQString(t, "x", LeptGetString(LeptGetObjectValue(v, 0)))
+	a := LeptGetObjectValue(v, 1)
+	expectEQString(t, "", LeptGetString(LeptGetArrayElement(a, 0)))
+	expectEQString(t, "a much longer value\n", LeptGetString(LeptGetArrayElement(a, 1)))
+	expectEQString(t, "y", LeptGetString(LeptGetArrayElement(a, 2)))
+	expectEQString(t, "\"", LeptGetObjectKey(LeptGetObjectValue(v, 2), 0))
+	expectEQString(t, "\t", LeptGetString(LeptGetObjectValue(LeptGetObjectValue(v, 2), 0)))
+	w := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(w, LeptStringify(v)))
+	expectEQBool(t, true, LeptIsEqual(v, w))
+
+	// a failed string leaves nothing behind for the next one
+	c := NewLeptContext("\"abc\\x\"")
+	_, event := LeptParseStringRaw(c)
+	expectEQLeptEvent(t, LeptParseInvalidStringEscape, event)
+	c.json, c.pos = "\"d\"", 0
+	s, event := LeptParseStringRaw(c)
+	expectEQLeptEvent(t, LeptParseOK, event)
+	expectEQString(t, "d", s)
+}
 func TestParseMissingQuotationMark(t *testing.T) {
 	valid := []struct {
 		input  string
@@ -299,6 +747,38 @@ func TestParseInvalidStringEscape(t *testing.T) {
 		expectEQLeptEvent(t, LeptParseInvalidStringEscape, LeptParse(v, c.input))
 	}
 }
+func TestParseUnterminatedStringEscape(t *testing.T) {
+	// the escape running into the end of input is always an error, never read past it
+	invalid := []struct {
+		input  string
+		expect LeptEvent
+	}{
+		{"\"\\", LeptParseInvalidStringEscape},
+		{"\"abc\\", LeptParseInvalidStringEscape},
+		{"[\"\\", LeptParseInvalidStringEscape},
+		{"{\"a\\", LeptParseInvalidStringEscape},
+		{"{\"a\":\"\\", LeptParseInvalidStringEscape},
+		{"\"\\u", LeptParseInvalidUnicodeHex},
+		{"\"\\u1", LeptParseInvalidUnicodeHex},
+		{"\"\\u12", LeptParseInvalidUnicodeHex},
+		{"\"\\u123", LeptParseInvalidUnicodeHex},
+		{"\"\\uD834\\", LeptParseInvalidUnicodeSurrogate},
+		{"\"\\uD834\\u", LeptParseInvalidUnicodeSurrogate},
+		{"\"\\uD834\\uDD1", LeptParseInvalidUnicodeSurrogate},
+		// the escape itself is complete, the closing quote is missing
+		{"\"\\u1234", LeptParseMissQuotationMark},
+		{"\"\\uD834\\uDD1E", LeptParseMissQuotationMark},
+		{"\"\\\\", LeptParseMissQuotationMark},
+		{"\"\\\"", LeptParseMissQuotationMark},
+	}
+	for _, c := range invalid {
+		expectEQLeptEvent(t, c.expect, LeptParse(NewLeptValue(), c.input))
+		// the same input cut out of a longer buffer behaves the same
+		ctx := NewLeptContext(c.input + "\"")
+		ctx.json = ctx.json[:len(c.input)]
+		expectEQLeptEvent(t, c.expect, leptParse(ctx, NewLeptValue()))
+	}
+}
 func TestParseInvalidStringChar(t *testing.T) {
 	valid := []struct {
 		input  string
@@ -329,6 +809,10 @@ func TestParseInvalidUnicodeHex(t *testing.T) {
 		{"\"\\u00G0\"", ""},
 		{"\"\\u000/\"", ""},
 		{"\"\\u000G\"", ""},
+		{"\"\\u00G1\"", ""},
+		{"\"\\u", ""},
+		{"\"\\u00", ""},
+		{"\"\\u012", ""},
 	}
 	for _, c := range valid {
 		v := NewLeptValue()
@@ -357,6 +841,8 @@ func TestParseArray(t *testing.T) {
 		expect string
 	}{
 		{"[ ]", "[ ]"},
+		{"[]", "[ ]"},
+		{" [\n\t] ", "[ ]"},
 	}
 	for _, c := range valid {
 		v := NewLeptValue()
@@ -364,6 +850,11 @@ func TestParseArray(t *testing.T) {
 		expectEQLeptType(t, LeptArray, LeptGetType(v))
 		expectEQInt(t, 0, LeptGetArraySize(v))
 	}
+	{
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[1,2,3]"))
+		expectEQString(t, "[1,2,3]", LeptStringify(v))
+	}
 	// [ null , false , true , 123 , "abc" ]
 	// [ [ ] , [ 0 ] , [ 0 , 1 ] , [ 0 , 1 , 2 ] ]
 	{
@@ -417,6 +908,7 @@ func TestParseMissCoomaOrSquareBracket(t *testing.T) {
 	for _, c := range valid {
 		v := NewLeptValue()
 		expectEQLeptEvent(t, LeptParseMissCommaOrSouareBracket, LeptParse(v, c.input))
+		expectEQLeptEvent(t, LeptParseMissCommaOrSquareBracket, LeptParse(v, c.input))
 	}
 }
 
@@ -555,6 +1047,44 @@ func TestAccessNumber(t *testing.T) {
 	expectEQLeptType(t, LeptNumber, LeptGetType(v))
 	expectEQFloat64(t, 123.123, LeptGetNumber(v))
 }
+func TestAccessInteger(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect int64
+	}{
+		{"0", 0},
+		{"-0", 0},
+		{"42", 42},
+		{"-42", -42},
+		{"1e3", 1000},
+		{"42.0", 42},
+		{"9007199254740993", 9007199254740992},
+		{"9223372036854774784", 9223372036854774784},
+		{"-9223372036854775808", math.MinInt64},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		n, ok := LeptGetInteger(v)
+		expectEQBool(t, true, ok)
+		if n != c.expect {
+			t.Errorf("LeptGetInteger %v, expect: %v, actual: %v", c.input, c.expect, n)
+		}
+	}
+	invalid := []string{"42.5", "-0.1", "1e-300", "9223372036854775807", "9223372036854775808", "-9223372036854777856", "1e300"}
+	for _, input := range invalid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+		_, ok := LeptGetInteger(v)
+		expectEQBool(t, false, ok)
+	}
+	expectPanic(t, "LeptGetInteger of nil", func() { LeptGetInteger(nil) })
+	expectPanic(t, "LeptGetInteger of string", func() {
+		v := NewLeptValue()
+		LeptSetString(v, "1")
+		LeptGetInteger(v)
+	})
+}
 func TestAccessString(t *testing.T) {
 	v := NewLeptValue()
 	LeptSetString(v, "")
@@ -567,6 +1097,318 @@ func TestAccessString(t *testing.T) {
 	expectEQString(t, "Hello", LeptGetString(v))
 }
 
+func TestLeptGetStringBytes(t *testing.T) {
+	for _, input := range []string{"\"\"", "\"Hello\"", "\"a\\u0000b\\u00e9\\uD834\\uDD1E\""} {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+		b := LeptGetStringBytes(v)
+		expectEQString(t, LeptGetString(v), string(b))
+		expectEQInt(t, LeptGetStringLength(v), len(b))
+	}
+	// the bytes are a copy
+	v := NewLeptValue()
+	LeptSetString(v, "Hello")
+	b := LeptGetStringBytes(v)
+	b[0] = 'J'
+	b = append(b, '!')
+	expectEQString(t, "Jello!", string(b))
+	expectEQString(t, "Hello", LeptGetString(v))
+	expectEQString(t, "Hello", string(LeptGetStringBytes(v)))
+	expectPanic(t, "LeptGetStringBytes of null", func() { LeptGetStringBytes(NewLeptValue()) })
+}
+
+func TestLeptEqualString(t *testing.T) {
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[\"Hello\",\"\",\"a\\u0000b\",null,false,1,[],{},\"1\"]"))
+	expectEQBool(t, true, LeptEqualString(LeptGetArrayElement(v, 0), "Hello"))
+	expectEQBool(t, false, LeptEqualString(LeptGetArrayElement(v, 0), "hello"))
+	expectEQBool(t, false, LeptEqualString(LeptGetArrayElement(v, 0), "Hello "))
+	expectEQBool(t, true, LeptEqualString(LeptGetArrayElement(v, 1), ""))
+	expectEQBool(t, true, LeptEqualString(LeptGetArrayElement(v, 2), "a\x00b"))
+	expectEQBool(t, false, LeptEqualString(LeptGetArrayElement(v, 2), "a"))
+	// the other types are never equal, even the empty string or the same text
+	for i, s := range []string{"", "false", "1", "", ""} {
+		expectEQBool(t, false, LeptEqualString(LeptGetArrayElement(v, 3+i), s))
+	}
+	expectEQBool(t, true, LeptEqualString(LeptGetArrayElement(v, 8), "1"))
+	expectEQBool(t, false, LeptEqualString(v, ""))
+	expectPanic(t, "LeptEqualString", func() { LeptEqualString(nil, "") })
+}
+
+func TestAccessArray(t *testing.T) {
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[[1,2],[3,[4]]]"))
+	expectEQInt(t, 2, LeptGetArraySize(v))
+	first := LeptGetArrayElement(v, 0)
+	expectEQInt(t, 2, LeptGetArraySize(first))
+	expectEQFloat64(t, 1, LeptGetNumber(LeptGetArrayElement(first, 0)))
+	expectEQFloat64(t, 2, LeptGetNumber(LeptGetArrayElement(first, 1)))
+	second := LeptGetArrayElement(v, 1)
+	expectEQInt(t, 2, LeptGetArraySize(second))
+	expectEQFloat64(t, 3, LeptGetNumber(LeptGetArrayElement(second, 0)))
+	inner := LeptGetArrayElement(second, 1)
+	expectEQInt(t, 1, LeptGetArraySize(inner))
+	expectEQFloat64(t, 4, LeptGetNumber(LeptGetArrayElement(inner, 0)))
+
+	expectPanic(t, "LeptGetArraySize of nil", func() { LeptGetArraySize(nil) })
+	expectPanic(t, "LeptGetArraySize of null", func() { LeptGetArraySize(NewLeptValue()) })
+	expectPanic(t, "LeptGetArrayElement of null", func() { LeptGetArrayElement(NewLeptValue(), 0) })
+	expectPanic(t, "LeptGetArrayElement with index 2", func() { LeptGetArrayElement(v, 2) })
+	expectPanic(t, "LeptGetArrayElement with index -1", func() { LeptGetArrayElement(v, -1) })
+}
+
+func TestAccessArrayMutation(t *testing.T) {
+	for _, capacity := range []int{0, 4, 1000} {
+		v := NewLeptValue()
+		LeptSetNumber(v, 1)
+		LeptSetArray(v, capacity)
+		expectEQLeptType(t, LeptArray, LeptGetType(v))
+		expectEQInt(t, 0, LeptGetArraySize(v))
+		for i := 0; i < 1000; i++ {
+			LeptSetNumber(LeptPushArrayElement(v), float64(i))
+		}
+		expectEQInt(t, 1000, LeptGetArraySize(v))
+		for i := 0; i < 1000; i++ {
+			expectEQFloat64(t, float64(i), LeptGetNumber(LeptGetArrayElement(v, i)))
+		}
+		for i := 0; i < 10; i++ {
+			LeptPopArrayElement(v)
+		}
+		expectEQInt(t, 990, LeptGetArraySize(v))
+		expectEQFloat64(t, 989, LeptGetNumber(LeptGetArrayElement(v, 989)))
+		// the pushed element after pop is fresh
+		expectEQLeptType(t, LeptNull, LeptGetType(LeptPushArrayElement(v)))
+		expectEQInt(t, 991, LeptGetArraySize(v))
+	}
+	v := NewLeptValue()
+	LeptSetArray(v, 0)
+	LeptSetString(LeptPushArrayElement(v), "a")
+	LeptSetArray(LeptPushArrayElement(v), 1)
+	LeptSetBoolean(LeptPushArrayElement(LeptGetArrayElement(v, 1)), 1)
+	expectEQString(t, "[\"a\",[true]]", LeptStringify(v))
+
+	expectPanic(t, "LeptSetArray with negative capacity", func() { LeptSetArray(NewLeptValue(), -1) })
+	expectPanic(t, "LeptPushArrayElement of null", func() { LeptPushArrayElement(NewLeptValue()) })
+	expectPanic(t, "LeptPopArrayElement of null", func() { LeptPopArrayElement(NewLeptValue()) })
+	expectPanic(t, "LeptPopArrayElement of empty array", func() {
+		v := NewLeptValue()
+		LeptSetArray(v, 0)
+		LeptPopArrayElement(v)
+	})
+}
+
+func TestAccessArrayCapacity(t *testing.T) {
+	v := NewLeptValue()
+	LeptSetArray(v, 16)
+	expectEQInt(t, 16, LeptGetArrayCapacity(v))
+	for i := 0; i < 100; i++ {
+		LeptSetNumber(LeptPushArrayElement(v), float64(i))
+	}
+	expectEQBool(t, true, LeptGetArrayCapacity(v) >= 100)
+	for i := 0; i < 90; i++ {
+		LeptPopArrayElement(v)
+	}
+	LeptShrinkArray(v)
+	expectEQInt(t, 10, LeptGetArraySize(v))
+	expectEQInt(t, 10, LeptGetArrayCapacity(v))
+	for i := 0; i < 10; i++ {
+		expectEQFloat64(t, float64(i), LeptGetNumber(LeptGetArrayElement(v, i)))
+	}
+	// shrinking again is a no-op, and the array still grows
+	LeptShrinkArray(v)
+	expectEQInt(t, 10, LeptGetArrayCapacity(v))
+	LeptSetNumber(LeptPushArrayElement(v), 10)
+	expectEQString(t, "[0,1,2,3,4,5,6,7,8,9,10]", LeptStringify(v))
+
+	LeptSetArray(v, 8)
+	LeptShrinkArray(v)
+	expectEQInt(t, 0, LeptGetArrayCapacity(v))
+	expectEQString(t, "[]", LeptStringify(v))
+
+	expectPanic(t, "LeptGetArrayCapacity of null", func() { LeptGetArrayCapacity(NewLeptValue()) })
+	expectPanic(t, "LeptShrinkArray of null", func() { LeptShrinkArray(NewLeptValue()) })
+	expectPanic(t, "LeptShrinkArray of nil", func() { LeptShrinkArray(nil) })
+}
+
+func TestAccessClearContainer(t *testing.T) {
+	a := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(a, "[1,[2],{\"a\":3}]"))
+	capacity := LeptGetArrayCapacity(a)
+	LeptClearArray(a)
+	expectEQLeptType(t, LeptArray, LeptGetType(a))
+	expectEQInt(t, 0, LeptGetArraySize(a))
+	expectEQInt(t, capacity, LeptGetArrayCapacity(a))
+	expectEQString(t, "[]", LeptStringify(a))
+	LeptSetString(LeptPushArrayElement(a), "x")
+	expectEQString(t, "[\"x\"]", LeptStringify(a))
+
+	o := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(o, "{\"a\":1,\"b\":[2]}"))
+	LeptClearObject(o)
+	expectEQLeptType(t, LeptObject, LeptGetType(o))
+	expectEQInt(t, 0, LeptGetObjectSize(o))
+	expectEQString(t, "{}", LeptStringify(o))
+	expectEQInt(t, LeptKeyNotExist, LeptFindObjectIndex(o, "a"))
+	LeptSetNumber(LeptSetObjectValue(o, "b"), 3)
+	expectEQString(t, "{\"b\":3}", LeptStringify(o))
+
+	// the empty ones stay empty
+	LeptSetArray(a, 0)
+	LeptClearArray(a)
+	expectEQString(t, "[]", LeptStringify(a))
+	LeptSetObject(o)
+	LeptClearObject(o)
+	expectEQString(t, "{}", LeptStringify(o))
+
+	expectPanic(t, "LeptClearArray of object", func() { LeptClearArray(o) })
+	expectPanic(t, "LeptClearObject of array", func() { LeptClearObject(a) })
+	expectPanic(t, "LeptClearArray of nil", func() { LeptClearArray(nil) })
+}
+
+func TestAccessArrayInsert(t *testing.T) {
+	valid := []struct {
+		index  int
+		expect string
+	}{
+		{0, "[\"x\",0,1,2,3,4]"},
+		{2, "[0,1,\"x\",2,3,4]"},
+		{4, "[0,1,2,3,\"x\",4]"},
+		{5, "[0,1,2,3,4,\"x\"]"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[0,1,2,3,4]"))
+		e := LeptInsertArrayElement(v, c.index)
+		expectEQLeptType(t, LeptNull, LeptGetType(e))
+		LeptSetString(e, "x")
+		expectEQInt(t, 6, LeptGetArraySize(v))
+		expectEQString(t, c.expect, LeptStringify(v))
+	}
+	// build an ordered list incrementally
+	v := NewLeptValue()
+	LeptSetArray(v, 0)
+	for _, n := range []int{5, 1, 3, 2, 4} {
+		i := 0
+		for i < LeptGetArraySize(v) && LeptGetNumber(LeptGetArrayElement(v, i)) < float64(n) {
+			i++
+		}
+		LeptSetNumber(LeptInsertArrayElement(v, i), float64(n))
+	}
+	expectEQString(t, "[1,2,3,4,5]", LeptStringify(v))
+
+	expectPanic(t, "LeptInsertArrayElement beyond size", func() { LeptInsertArrayElement(v, 6) })
+	expectPanic(t, "LeptInsertArrayElement with index -1", func() { LeptInsertArrayElement(v, -1) })
+	expectPanic(t, "LeptInsertArrayElement of null", func() { LeptInsertArrayElement(NewLeptValue(), 0) })
+}
+
+func TestAccessArrayErase(t *testing.T) {
+	valid := []struct {
+		index  int
+		count  int
+		expect string
+	}{
+		{1, 2, "[0,3,4]"},
+		{0, 1, "[1,2,3,4]"},
+		{4, 1, "[0,1,2,3]"},
+		{0, 5, "[]"},
+		{2, 0, "[0,1,2,3,4]"},
+		{5, 0, "[0,1,2,3,4]"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[0,1,2,3,4]"))
+		LeptEraseArrayElement(v, c.index, c.count)
+		expectEQString(t, c.expect, LeptStringify(v))
+	}
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[0,1,2,3,4]"))
+	LeptEraseArrayElement(v, 1, 2)
+	// the erased elements are gone, the capacity is reused
+	LeptSetNumber(LeptPushArrayElement(v), 5)
+	expectEQString(t, "[0,3,4,5]", LeptStringify(v))
+
+	expectPanic(t, "LeptEraseArrayElement past the end", func() { LeptEraseArrayElement(v, 3, 2) })
+	expectPanic(t, "LeptEraseArrayElement beyond size", func() { LeptEraseArrayElement(v, 5, 0) })
+	expectPanic(t, "LeptEraseArrayElement with index -1", func() { LeptEraseArrayElement(v, -1, 1) })
+	expectPanic(t, "LeptEraseArrayElement with count -1", func() { LeptEraseArrayElement(v, 1, -1) })
+	expectPanic(t, "LeptEraseArrayElement of null", func() { LeptEraseArrayElement(NewLeptValue(), 0, 0) })
+}
+
+func TestAccessObjectMember(t *testing.T) {
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{\"n\":null,\"f\":false,\"a\":[1,2]}"))
+	expectEQInt(t, 3, LeptGetObjectSize(v))
+	keys := []string{"n", "f", "a"}
+	types := []LeptType{LeptNull, LeptFalse, LeptArray}
+	for i := 0; i < LeptGetObjectSize(v); i++ {
+		expectEQString(t, keys[i], LeptGetObjectKey(v, i))
+		expectEQInt(t, len(keys[i]), LeptGetObjectKeyLength(v, i))
+		expectEQLeptType(t, types[i], LeptGetType(LeptGetObjectValue(v, i)))
+	}
+	a := LeptGetObjectValue(v, 2)
+	expectEQInt(t, 2, LeptGetArraySize(a))
+	expectEQFloat64(t, 1, LeptGetNumber(LeptGetArrayElement(a, 0)))
+	expectEQFloat64(t, 2, LeptGetNumber(LeptGetArrayElement(a, 1)))
+
+	expectPanic(t, "LeptGetObjectSize of nil", func() { LeptGetObjectSize(nil) })
+	expectPanic(t, "LeptGetObjectSize of array", func() { LeptGetObjectSize(a) })
+	expectPanic(t, "LeptGetObjectKey of array", func() { LeptGetObjectKey(a, 0) })
+	expectPanic(t, "LeptGetObjectKeyLength of array", func() { LeptGetObjectKeyLength(a, 0) })
+	expectPanic(t, "LeptGetObjectValue of array", func() { LeptGetObjectValue(a, 0) })
+	for _, index := range []int{-1, 3} {
+		expectPanic(t, "LeptGetObjectKey out of range", func() { LeptGetObjectKey(v, index) })
+		expectPanic(t, "LeptGetObjectKeyLength out of range", func() { LeptGetObjectKeyLength(v, index) })
+		expectPanic(t, "LeptGetObjectValue out of range", func() { LeptGetObjectValue(v, index) })
+	}
+}
+
+func TestAccessObjectMutation(t *testing.T) {
+	o := NewLeptValue()
+	LeptSetObject(o)
+	LeptSetNumber(LeptSetObjectValue(o, "a"), 1)
+	LeptSetNumber(LeptSetObjectValue(o, "a"), 2)
+	expectEQInt(t, 1, LeptGetObjectSize(o))
+	expectEQFloat64(t, 2, LeptGetNumber(LeptFindObjectValue(o, "a")))
+
+	for _, key := range []string{"b", "c", "d"} {
+		LeptSetString(LeptSetObjectValue(o, key), key)
+	}
+	// remove from the middle shifts the rest down
+	LeptRemoveObjectValue(o, 1)
+	expectEQInt(t, 3, LeptGetObjectSize(o))
+	expectEQString(t, "a", LeptGetObjectKey(o, 0))
+	expectEQString(t, "c", LeptGetObjectKey(o, 1))
+	expectEQString(t, "d", LeptGetObjectKey(o, 2))
+	expectEQString(t, "c", LeptGetString(LeptGetObjectValue(o, 1)))
+	expectEQString(t, "{\"a\":2,\"c\":\"c\",\"d\":\"d\"}", LeptStringify(o))
+
+	expectPanic(t, "LeptSetObjectValue of null", func() { LeptSetObjectValue(NewLeptValue(), "a") })
+	expectPanic(t, "LeptRemoveObjectValue out of range", func() { LeptRemoveObjectValue(o, 3) })
+	expectPanic(t, "LeptRemoveObjectValue with index -1", func() { LeptRemoveObjectValue(o, -1) })
+}
+
+func TestFindObject(t *testing.T) {
+	o := NewLeptValue()
+	LeptSetObject(o)
+	expectEQInt(t, LeptKeyNotExist, LeptFindObjectIndex(o, "a"))
+	expectEQInt(t, LeptKeyNotExist, LeptFindObjectIndex(o, ""))
+	expectEQBool(t, true, LeptFindObjectValue(o, "a") == nil)
+
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(o, "{\"a\":1,\"\":\"empty\",\"b\":[true]}"))
+	expectEQInt(t, 0, LeptFindObjectIndex(o, "a"))
+	expectEQInt(t, 1, LeptFindObjectIndex(o, ""))
+	expectEQInt(t, 2, LeptFindObjectIndex(o, "b"))
+	expectEQInt(t, LeptKeyNotExist, LeptFindObjectIndex(o, "A"))
+	expectEQFloat64(t, 1, LeptGetNumber(LeptFindObjectValue(o, "a")))
+	expectEQString(t, "empty", LeptGetString(LeptFindObjectValue(o, "")))
+	expectEQInt(t, 1, LeptGetArraySize(LeptFindObjectValue(o, "b")))
+	expectEQBool(t, true, LeptFindObjectValue(o, "c") == nil)
+
+	expectPanic(t, "LeptFindObjectIndex of nil", func() { LeptFindObjectIndex(nil, "a") })
+	expectPanic(t, "LeptFindObjectIndex of array", func() { LeptFindObjectIndex(LeptFindObjectValue(o, "b"), "a") })
+	expectPanic(t, "LeptFindObjectValue of null", func() { LeptFindObjectValue(NewLeptValue(), "a") })
+}
+
 func TestAccessObject(t *testing.T) {
 	o := NewLeptValue()
 	for j := 0; j <= 5; j += 5 {
@@ -576,12 +1418,12 @@ func TestAccessObject(t *testing.T) {
 			key := 'a' + i
 			v := NewLeptValue()
 			LeptSetNumber(v, float64(i))
-			LeptMove(LeptSetObjectValue(o, string(key)), v)
+			LeptMove(LeptSetObjectValue(o, string(rune(key))), v)
 		}
 		expectEQInt(t, 10, LeptGetObjectSize(o))
 		for i := 0; i < 10; i++ {
 			key := 'a' + i
-			index := LeptFindObjectIndex(o, string(key))
+			index := LeptFindObjectIndex(o, string(rune(key)))
 			expectEQBool(t, true, index-LeptKeyNotExist != 0)
 			pv := LeptGetObjectValue(o, index)
 			expectEQFloat64(t, float64(i), LeptGetNumber(pv))
@@ -606,7 +1448,7 @@ func TestAccessObject(t *testing.T) {
 	{
 		for i := 0; i < 8; i++ {
 			key := 'a' + i + 1
-			index := LeptFindObjectIndex(o, string(key))
+			index := LeptFindObjectIndex(o, string(rune(key)))
 			expectEQBool(t, true, index-LeptKeyNotExist != 0)
 			pv := LeptGetObjectValue(o, index)
 			expectEQFloat64(t, float64(i+1), LeptGetNumber(pv))
@@ -621,6 +1463,144 @@ func TestAccessObject(t *testing.T) {
 		expectEQString(t, "Hello", LeptGetString(pv))
 	}
 }
+func TestLeptParseArrayHead(t *testing.T) {
+	valid := []struct {
+		input  string
+		n      int
+		expect string
+	}{
+		{"[]", 3, "[]"},
+		{" [ ] ", 0, "[]"},
+		{"[1, 2, 3]", 0, "[]"},
+		{"[1, 2, 3]", 2, "[1,2]"},
+		{"[1, 2, 3]", 3, "[1,2,3]"},
+		{"[1, 2, 3]", 10, "[1,2,3]"},
+		{"[[1, 2], {\"a\":[3]}, 4]", 2, "[[1,2],{\"a\":[3]}]"},
+		// never reach the invalid json after the nth element
+		{"[1, 2, ???", 2, "[1,2]"},
+		{"[\"a\", {\"b\":null} tru", 2, "[\"a\",{\"b\":null}]"},
+		{"[1" + strings.Repeat(", {", 10000), 1, "[1]"},
+		{"[?", 0, "[]"},
+	}
+	for _, c := range valid {
+		v, event := LeptParseArrayHead(c.input, c.n)
+		expectEQLeptEvent(t, LeptParseOK, event)
+		if v == nil {
+			t.Errorf("LeptParseArrayHead %v expect value", c.input)
+			continue
+		}
+		expectEQString(t, c.expect, LeptStringify(v))
+	}
+	invalid := []struct {
+		input  string
+		n      int
+		expect LeptEvent
+	}{
+		{"", 1, LeptParseExpectValue},
+		{"{}", 1, LeptParseInvalidValue},
+		{"1", 1, LeptParseInvalidValue},
+		{"[1 2]", 2, LeptParseMissCommaOrSouareBracket},
+		{"[1, ?]", 2, LeptParseInvalidValue},
+		{"[1,", 2, LeptParseExpectValue},
+	}
+	for _, c := range invalid {
+		v, event := LeptParseArrayHead(c.input, c.n)
+		expectEQLeptEvent(t, c.expect, event)
+		expectEQBool(t, true, v == nil)
+	}
+}
+
+func TestLeptObjectKeys(t *testing.T) {
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{\"b\":1,\"c\":2,\"a\":3,\"B\":4}"))
+	expect := []string{"b", "c", "a", "B"}
+	if keys := LeptObjectKeys(v); !reflect.DeepEqual(expect, keys) {
+		t.Errorf("LeptObjectKeys expect: %v, actual: %v", expect, keys)
+	}
+	expect = []string{"B", "a", "b", "c"}
+	if keys := LeptObjectKeysSorted(v); !reflect.DeepEqual(expect, keys) {
+		t.Errorf("LeptObjectKeysSorted expect: %v, actual: %v", expect, keys)
+	}
+	// the snapshot is not affected by later mutation
+	keys := LeptObjectKeys(v)
+	LeptRemoveObjectValue(v, 0)
+	expectEQString(t, "b", keys[0])
+
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{}"))
+	expectEQInt(t, 0, len(LeptObjectKeys(v)))
+	expectEQInt(t, 0, len(LeptObjectKeysSorted(v)))
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("LeptObjectKeys should panic for non object")
+		}
+	}()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[]"))
+	LeptObjectKeys(v)
+}
+
+func TestLeptValueDepth(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect int
+	}{
+		{"null", 0},
+		{"1", 0},
+		{"\"abc\"", 0},
+		{"[]", 1},
+		{"{}", 1},
+		{"[1, 2, \"a\"]", 1},
+		{"{\"a\":1,\"b\":true}", 1},
+		{"[[]]", 2},
+		{"[1, {\"a\":[2]}, []]", 3},
+		{"{\"a\":{\"b\":{\"c\":{}}},\"d\":[]}", 4},
+		{strings.Repeat("[", 100) + strings.Repeat("]", 100), 100},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQInt(t, c.expect, LeptValueDepth(v))
+	}
+}
+
+func TestLeptObjectLooksLikeArray(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"{\"0\":\"a\"}", "[\"a\"]"},
+		{"{\"0\":1,\"1\":2,\"2\":3}", "[1,2,3]"},
+		{"{\"1\":2,\"0\":1,\"2\":{\"0\":true}}", "[1,2,{\"0\":true}]"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQBool(t, true, LeptObjectLooksLikeArray(v))
+		a := LeptObjectToArray(v)
+		expectEQLeptType(t, LeptArray, LeptGetType(a))
+		expectEQString(t, c.expect, LeptStringify(a))
+	}
+	invalid := []struct {
+		input string
+	}{
+		{"{}"},
+		{"[]"},
+		{"null"},
+		{"{\"1\":1}"},
+		{"{\"0\":1,\"2\":2}"},
+		{"{\"0\":1,\"01\":2}"},
+		{"{\"0\":1,\"0\":2}"},
+		{"{\"0\":1,\"-1\":2}"},
+		{"{\"0\":1,\"a\":2}"},
+		{"{\"0\":1,\"\":2}"},
+	}
+	for _, c := range invalid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQBool(t, false, LeptObjectLooksLikeArray(v))
+		expectEQBool(t, true, LeptObjectToArray(v) == nil)
+	}
+}
 func TestLeptStringify(t *testing.T) {
 	bases := []struct {
 		input string
@@ -651,10 +1631,10 @@ func TestLeptStringify(t *testing.T) {
 		{"1.234e-20"},
 
 		{"1.0000000000000002"},
-		{"4.9406564584124654e-324"},
-		{"-4.9406564584124654e-324"},
-		{"2.2250738585072009e-308"},
-		{"-2.2250738585072009e-308"},
+		{"5e-324"},
+		{"-5e-324"},
+		{"2.225073858507201e-308"},
+		{"-2.225073858507201e-308"},
 		{"2.2250738585072014e-308"},
 		{"-2.2250738585072014e-308"},
 		{"1.7976931348623157e+308"},
@@ -667,6 +1647,17 @@ func TestLeptStringify(t *testing.T) {
 		expectEQString(t, c.input, actual)
 		// 不完全正确的解析
 	}
+	// LeptStringify and the default options write the same shortest form
+	for _, n := range []float64{0.1, 0.3, 1.0 / 3, 100, 1e21, 5e-324, 2.2250738585072009e-308, math.MaxFloat64} {
+		v := NewLeptValue()
+		LeptSetNumber(v, n)
+		expectEQString(t, LeptStringifyWithOptions(v, nil), LeptStringify(v))
+		expectEQString(t, LeptStringify(v), LeptGetNumberText(v))
+	}
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "0.1"))
+	expectEQString(t, "0.1", LeptStringify(v))
+	expectEQString(t, "0.1", LeptGetNumberText(v))
 
 	strings := []struct {
 		input string
@@ -709,8 +1700,110 @@ func TestLeptStringify(t *testing.T) {
 		actual := LeptStringify(v)
 		expectEQString(t, c.input, actual)
 	}
+
+	// the output is canonical and parses back to the same value
+	canonicals := []struct {
+		input  string
+		expect string
+	}{
+		{" null ", "null"},
+		{"1.50", "1.5"},
+		{"1E2", "100"},
+		{"-0.0", "-0"},
+		{"\"\\/\"", "\"/\""},
+		{"\"\\u0041\\u00e9\"", "\"A\u00e9\""},
+		{"[ 1 , [ ] , { } ]", "[1,[],{}]"},
+		{"{ \"a\" : [ true , \"x\" ] , \"b\" : { \"c\" : null } }", "{\"a\":[true,\"x\"],\"b\":{\"c\":null}}"},
+	}
+	for _, c := range canonicals {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		actual := LeptStringify(v)
+		expectEQString(t, c.expect, actual)
+		v2 := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v2, actual))
+		expectEQBool(t, true, LeptIsEqual(v, v2))
+	}
+}
+
+func TestLeptStringifyControlChars(t *testing.T) {
+	short := map[byte]string{'\b': "\\b", '\f': "\\f", '\n': "\\n", '\r': "\\r", '\t': "\\t"}
+	for ch := 0; ch < 0x20; ch++ {
+		expect, ok := short[byte(ch)]
+		if !ok {
+			expect = fmt.Sprintf("\\u%04X", ch)
+		}
+		v := NewLeptValue()
+		LeptSetString(v, "a"+string(rune(ch))+"b")
+		expectEQString(t, "\"a"+expect+"b\"", LeptStringify(v))
+	}
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"\x01", "\"\\u0001\""},
+		{"\x00\x1F", "\"\\u0000\\u001F\""},
+		{"\"\\", "\"\\\"\\\\\""},
+		{"\x7F /", "\"\x7F /\""},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		LeptSetString(v, c.input)
+		expectEQString(t, c.expect, LeptStringify(v))
+	}
+}
+
+func TestLeptStringifyKeyOrder(t *testing.T) {
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{\"z\":1,\"a\":2,\"m\":3}"))
+	expectEQString(t, "{\"z\":1,\"a\":2,\"m\":3}", LeptStringify(v))
+	// a new key goes last, an existing key keeps its place
+	LeptSetNumber(LeptSetObjectValue(v, "b"), 4)
+	LeptSetNumber(LeptSetObjectValue(v, "z"), 5)
+	expectEQString(t, "{\"z\":5,\"a\":2,\"m\":3,\"b\":4}", LeptStringify(v))
+	LeptRemoveObjectValue(v, 1)
+	expectEQString(t, "{\"z\":5,\"m\":3,\"b\":4}", LeptStringify(v))
+	c := NewLeptValue()
+	LeptCopy(c, v)
+	expectEQString(t, "{\"z\":5,\"m\":3,\"b\":4}", LeptStringify(c))
+	for i := 0; i < 10; i++ {
+		w := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(w, LeptStringify(v)))
+		expectEQString(t, LeptStringify(v), LeptStringify(w))
+	}
 }
 
+func TestLeptNumberRoundTrips(t *testing.T) {
+	numbers := []string{
+		"0", "-0", "1", "-1", "0.1", "0.2", "0.3", "1e-7", "123456789012345678",
+		"1.0000000000000002", "4.9406564584124654e-324", "2.2250738585072009e-308",
+		"2.2250738585072014e-308", "1.7976931348623157e+308", "-1.7976931348623157e+308",
+	}
+	for _, s := range numbers {
+		expectEQBool(t, true, LeptNumberRoundTrips(s))
+	}
+	for _, s := range []string{"", "abc", "[1]", "1e309", "+1"} {
+		expectEQBool(t, false, LeptNumberRoundTrips(s))
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		f := math.Float64frombits(r.Uint64())
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+		for _, s := range []string{
+			strconv.FormatFloat(f, 'g', -1, 64),
+			strconv.FormatFloat(f, 'e', 17, 64),
+			strconv.FormatFloat(f, 'e', r.Intn(17), 64),
+			strconv.FormatFloat(r.NormFloat64()*1e6, 'f', r.Intn(10), 64),
+			strconv.FormatInt(r.Int63()>>uint(r.Intn(63)), 10),
+		} {
+			if !LeptNumberRoundTrips(s) {
+				t.Errorf("LeptNumberRoundTrips %v should be true", s)
+			}
+		}
+	}
+}
 func TestLeptIsEqual(t *testing.T) {
 	valid := []struct {
 		inputLeft  string
@@ -740,6 +1833,10 @@ func TestLeptIsEqual(t *testing.T) {
 		{"{\"a\":1,\"b\":2}", "{\"a\":1,\"b\":2,\"c\":3}", false},
 		{"{\"a\":{\"b\":{\"c\":{}}}}", "{\"a\":{\"b\":{\"c\":{}}}}", true},
 		{"{\"a\":{\"b\":{\"c\":{}}}}", "{\"a\":{\"b\":{\"c\":[]}}}", false},
+		{"[1,2]", "[2,1]", false},
+		{"{\"x\":{\"p\":[1,{\"m\":\"s\",\"n\":null}],\"q\":true},\"y\":0}", "{\"y\":0,\"x\":{\"q\":true,\"p\":[1,{\"n\":null,\"m\":\"s\"}]}}", true},
+		{"{\"x\":{\"p\":[1,{\"m\":\"s\",\"n\":null}],\"q\":true},\"y\":0}", "{\"y\":0,\"x\":{\"q\":true,\"p\":[{\"n\":null,\"m\":\"s\"},1]}}", false},
+		{"{\"a\":1,\"b\":2}", "{\"a\":1,\"c\":2}", false},
 	}
 	for _, c := range valid {
 		vl, vr := NewLeptValue(), NewLeptValue()
@@ -748,11 +1845,62 @@ func TestLeptIsEqual(t *testing.T) {
 		expectEQBool(t, c.expect, LeptIsEqual(vl, vr))
 	}
 }
+func TestLeptNormalize(t *testing.T) {
+	valid := []struct {
+		inputLeft  string
+		inputRight string
+		expect     string
+	}{
+		{"1.50", "15e-1", "1.5"},
+		{"-0", "0.0", "0"},
+		{"\"\\u0061\"", "\"a\"", "\"a\""},
+		{"{\"b\" : 1, \"a\" : [ 1.0 , {\"d\":null,\"c\":true} ]}", "{\"a\":[1,{\"c\":true,\"d\":null}],\"b\":1e0}", "{\"a\":[1,{\"c\":true,\"d\":null}],\"b\":1}"},
+		{"[ ]", "[]", "[]"},
+		{"{ }", "{}", "{}"},
+	}
+	for _, c := range valid {
+		vl, vr := NewLeptValue(), NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(vl, c.inputLeft))
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(vr, c.inputRight))
+		nl, nr := LeptNormalize(vl), LeptNormalize(vr)
+		expectEQBool(t, true, LeptIsEqual(nl, nr))
+		expectEQString(t, c.expect, LeptStringify(nl))
+		expectEQString(t, c.expect, LeptStringify(nr))
+	}
+	// v is not changed
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{\"b\":1,\"a\":2}"))
+	LeptNormalize(v)
+	expectEQString(t, "{\"b\":1,\"a\":2}", LeptStringify(v))
+}
 func TestLeptCopy(t *testing.T) {
 	vl, vr := NewLeptValue(), NewLeptValue()
 	LeptParse(vl, "{\"t\":true,\"f\":false,\"n\":null,\"d\":1.5,\"a\":[1,2,3]}")
 	LeptCopy(vr, vl)
 	expectEQBool(t, true, LeptIsEqual(vl, vr))
+
+	// mutating the copy does not affect the original
+	src, dst := NewLeptValue(), NewLeptValue()
+	input := "{\"s\":\"abc\",\"o\":{\"a\":[1,{\"b\":2}]},\"n\":1}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(src, input))
+	LeptCopy(dst, src)
+	LeptSetString(LeptFindObjectValue(dst, "s"), "xyz")
+	LeptSetNumber(LeptFindObjectValue(dst, "n"), 2)
+	a := LeptFindObjectValue(LeptFindObjectValue(dst, "o"), "a")
+	LeptSetNumber(LeptGetArrayElement(a, 0), 10)
+	LeptSetNumber(LeptSetObjectValue(LeptGetArrayElement(a, 1), "b"), 20)
+	LeptSetNull(LeptSetObjectValue(LeptGetArrayElement(a, 1), "c"))
+	LeptPushArrayElement(a)
+	LeptRemoveObjectValue(dst, 0)
+	expectEQString(t, input, LeptStringify(src))
+	expectEQString(t, "{\"o\":{\"a\":[10,{\"b\":20,\"c\":null},null]},\"n\":2}", LeptStringify(dst))
+
+	// copy into a container replaces its content
+	LeptCopy(dst, LeptFindObjectValue(LeptFindObjectValue(src, "o"), "a"))
+	expectEQString(t, "[1,{\"b\":2}]", LeptStringify(dst))
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(dst, "{\"x\":1}"))
+	LeptCopy(dst, LeptFindObjectValue(src, "o"))
+	expectEQString(t, "{\"a\":[1,{\"b\":2}]}", LeptStringify(dst))
 }
 func TestLeptMove(t *testing.T) {
 	vl, vr, vo := NewLeptValue(), NewLeptValue(), NewLeptValue()
@@ -762,6 +1910,22 @@ func TestLeptMove(t *testing.T) {
 	LeptMove(vo, vr)
 	expectEQLeptType(t, LeptNull, LeptGetType(vr))
 	expectEQBool(t, true, LeptIsEqual(vo, vl))
+	// moving into a container replaces it, moving from a string resets it
+	LeptSetString(vr, "abc")
+	LeptMove(vo, vr)
+	expectEQLeptType(t, LeptNull, LeptGetType(vr))
+	expectEQString(t, "abc", LeptGetString(vo))
+	expectEQString(t, "", vr.s)
+
+	// the element got from an interned array is a copy, the shared value is left as is
+	opts := NewLeptParseOptions()
+	opts.InternSmallIntegers = true
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(vl, "[1,1]", opts))
+	shared := vl.a[1]
+	LeptMove(LeptGetArrayElement(vl, 0), vo)
+	expectEQString(t, "[\"abc\",1]", LeptStringify(vl))
+	expectEQFloat64(t, 1, LeptGetNumber(shared))
+	expectPanic(t, "LeptMove into shared value", func() { LeptMove(shared, vr) })
 }
 func TestLeptSwap(t *testing.T) {
 	vl, vr := NewLeptValue(), NewLeptValue()
@@ -770,6 +1934,84 @@ func TestLeptSwap(t *testing.T) {
 	LeptSwap(vl, vr)
 	expectEQString(t, "World", LeptGetString(vl))
 	expectEQString(t, "Hello", LeptGetString(vr))
+
+	// the type and payload are exchanged together
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(vr, "[1,{\"a\":true}]"))
+	LeptSwap(vl, vr)
+	expectEQLeptType(t, LeptArray, LeptGetType(vl))
+	expectEQString(t, "[1,{\"a\":true}]", LeptStringify(vl))
+	expectEQLeptType(t, LeptString, LeptGetType(vr))
+	expectEQString(t, "World", LeptGetString(vr))
+	LeptSetNumber(vr, 2)
+	LeptSwap(vl, vr)
+	expectEQFloat64(t, 2, LeptGetNumber(vl))
+	expectEQInt(t, 2, LeptGetArraySize(vr))
+}
+
+func TestLeptToInterface(t *testing.T) {
+	inputs := []string{
+		"null", "true", "false", "-1.5e3", "\"a\\u00e9\\n\"", "[]", "{}",
+		"{\"n\":null,\"f\":false,\"t\":true,\"i\":123,\"d\":0.1,\"s\":\"abc\",\"e\":[],\"m\":{}," +
+			"\"a\":[1,\"x\",[null,{\"k\":[true]}]],\"o\":{\"1\":1,\"\":{\"deep\":[[[]]]}}}",
+		"[{\"a\":1,\"a\":2}]",
+	}
+	for _, input := range inputs {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+		var expect interface{}
+		if err := json.Unmarshal([]byte(input), &expect); err != nil {
+			t.Errorf("json.Unmarshal %q: %v", input, err)
+			continue
+		}
+		if actual := LeptToInterface(v); !reflect.DeepEqual(expect, actual) {
+			t.Errorf("LeptToInterface %q expect: %#v, actual: %#v", input, expect, actual)
+		}
+	}
+	expectPanic(t, "LeptToInterface", func() { LeptToInterface(nil) })
+}
+
+func TestLeptFromInterface(t *testing.T) {
+	data := map[string]interface{}{
+		"name": "goleptjson",
+		"tags": []interface{}{"json", 1, int64(-2), uint8(3), float32(0.5), 1.25, true, false, nil},
+		"meta": map[string]interface{}{"stars": 0, "list": []interface{}{}, "map": map[string]interface{}{}},
+	}
+	v := NewLeptValue()
+	if err := LeptFromInterface(v, data); err != nil {
+		t.Errorf("LeptFromInterface expect no err: %v", err)
+	}
+	expect := "{\"meta\":{\"list\":[],\"map\":{},\"stars\":0},\"name\":\"goleptjson\"," +
+		"\"tags\":[\"json\",1,-2,3,0.5,1.25,true,false,null]}"
+	expectEQString(t, expect, LeptStringify(v))
+	// the inverse of LeptToInterface
+	w := NewLeptValue()
+	if err := LeptFromInterface(w, LeptToInterface(v)); err != nil {
+		t.Errorf("LeptFromInterface expect no err: %v", err)
+	}
+	expectEQBool(t, true, LeptIsEqual(v, w))
+
+	invalid := []struct {
+		data   interface{}
+		expect string
+	}{
+		{[]int{1}, "goleptjson: LeptFromInterface unsupported type []int"},
+		{map[string]string{}, "goleptjson: LeptFromInterface unsupported type map[string]string"},
+		{struct{}{}, "goleptjson: LeptFromInterface unsupported type struct {}"},
+		{[]interface{}{1, map[string]interface{}{"c": make(chan int)}}, "goleptjson: LeptFromInterface unsupported type chan int"},
+		{math.NaN(), "goleptjson: LeptFromInterface unsupported number NaN"},
+		{[]interface{}{math.Inf(-1)}, "goleptjson: LeptFromInterface unsupported number -Inf"},
+	}
+	for _, c := range invalid {
+		err := LeptFromInterface(v, c.data)
+		if err == nil {
+			t.Errorf("LeptFromInterface %#v expect err", c.data)
+			continue
+		}
+		expectEQString(t, c.expect, err.Error())
+		// v is untouched
+		expectEQString(t, expect, LeptStringify(v))
+	}
+	expectPanic(t, "LeptFromInterface", func() { LeptFromInterface(nil, nil) })
 }
 
 func TestToMap(t *testing.T) {
@@ -1191,6 +2433,176 @@ func TestUnMarshal(t *testing.T) {
 		fmt.Println(estruct)
 	}
 }
+func TestUnmarshalTagged(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+		Zip  uint16 `json:"zip"`
+	}
+	type User struct {
+		ID       int64             `json:"id"`
+		Name     string            `json:"name"`
+		Score    float32           `json:"score"`
+		Active   bool              `json:"active"`
+		Address  Address           `json:"address"`
+		Others   []Address         `json:"others"`
+		Labels   map[string]string `json:"labels"`
+		Ptr      *Address          `json:"ptr"`
+		Missing  string            `json:"missing"`
+		Skipped  int               `json:"-"`
+		Untagged int
+		hidden   int
+	}
+	input := "{\"id\":42,\"name\":\"bob\",\"score\":1.5,\"active\":true," +
+		"\"address\":{\"city\":\"x\",\"zip\":123},\"others\":[{\"city\":\"y\"},{\"zip\":7}]," +
+		"\"labels\":{\"a\":\"b\"},\"ptr\":{\"city\":\"z\"},\"Skipped\":1,\"Untagged\":3,\"hidden\":4,\"extra\":1}"
+	var u User
+	if err := Unmarshal([]byte(input), &u); err != nil {
+		t.Errorf("Unmarshal expect no err: %v", err)
+	}
+	expect := User{
+		ID: 42, Name: "bob", Score: 1.5, Active: true,
+		Address: Address{City: "x", Zip: 123},
+		Others:  []Address{{City: "y"}, {Zip: 7}},
+		Labels:  map[string]string{"a": "b"},
+		Ptr:     &Address{City: "z"},
+		// absent fields keep the zero value
+		Untagged: 3,
+	}
+	if !reflect.DeepEqual(expect, u) {
+		t.Errorf("Unmarshal expect: %+v, actual: %+v", expect, u)
+	}
+	var e User
+	if err := json.Unmarshal([]byte(input), &e); err != nil || !reflect.DeepEqual(e, u) {
+		t.Errorf("json.Unmarshal err: %v, actual: %+v", err, e)
+	}
+
+	// null clears the pointer
+	u.Ptr = &Address{City: "old"}
+	if err := Unmarshal([]byte("{\"ptr\":null}"), &u); err != nil || u.Ptr != nil {
+		t.Errorf("Unmarshal null expect nil pointer, err: %v, actual: %v", err, u.Ptr)
+	}
+
+	// the untagged field matches the key case-insensitively, the exact key wins
+	type Profile struct {
+		UserName string
+		Email    string
+		Nick     string `json:"nick"`
+	}
+	input = "{\"username\":\"a\",\"EMAIL\":\"b\",\"email\":\"c\",\"Email\":\"d\",\"NICK\":\"e\"}"
+	var p, ep Profile
+	if err := Unmarshal([]byte(input), &p); err != nil {
+		t.Errorf("Unmarshal expect no err: %v", err)
+	}
+	if p != (Profile{UserName: "a", Email: "d"}) {
+		t.Errorf("Unmarshal untagged expect case-insensitive match, actual: %+v", p)
+	}
+	if err := json.Unmarshal([]byte("{\"username\":\"a\",\"EMAIL\":\"b\"}"), &ep); err != nil || ep.UserName != p.UserName || ep.Email != "b" {
+		t.Errorf("json.Unmarshal err: %v, actual: %+v", err, ep)
+	}
+
+	err := Unmarshal([]byte("{\"id\":"), &u)
+	if le, ok := err.(*LeptError); !ok || le.Event != LeptParseExpectValue || le.Offset != 6 {
+		t.Errorf("Unmarshal expect *LeptError, actual: %v", err)
+	}
+}
+func TestMarshalNested(t *testing.T) {
+	type Address struct {
+		City string `json:"city,omitempty"`
+		Zip  uint16 `json:"zip"`
+	}
+	type User struct {
+		ID       int64          `json:"id"`
+		Name     string         `json:"name"`
+		Score    float64        `json:"score"`
+		Active   bool           `json:"active,omitempty"`
+		Address  Address        `json:"address"`
+		Others   []*Address     `json:"others"`
+		Labels   map[string]int `json:"labels"`
+		Ptr      *Address       `json:"ptr,omitempty"`
+		Nil      *Address       `json:"nil"`
+		Tags     []string       `json:"tags,omitempty"`
+		Skipped  int            `json:"-"`
+		Untagged int
+		hidden   int
+	}
+	valid := []User{
+		{},
+		{
+			ID: 1, Name: "a\"b\\c\n", Score: 1.5, Active: true,
+			Address: Address{Zip: 1},
+			Others:  []*Address{{City: "x"}, nil},
+			Labels:  map[string]int{"b": 1, "a": 2},
+			Ptr:     &Address{City: "y", Zip: 2},
+			Tags:    []string{"t"},
+			Skipped: 1, Untagged: 3, hidden: 4,
+		},
+	}
+	for _, u := range valid {
+		buf, err := Marshal(u)
+		if err != nil {
+			t.Errorf("Marshal expect no err: %v", err)
+		}
+		ebuf, err := json.Marshal(u)
+		if err != nil {
+			t.Errorf("json.Marshal expect no err: %v", err)
+		}
+		expectEQString(t, string(ebuf), string(buf))
+		// the pointer is marshaled as the value it points to
+		pbuf, _ := Marshal(&u)
+		expectEQString(t, string(buf), string(pbuf))
+	}
+}
+type leptRawMarshaler struct {
+	raw string
+}
+
+func (m leptRawMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(m.raw), nil
+}
+
+func TestMarshalTree(t *testing.T) {
+	// the numbers stay exact through the LeptValue tree, MarshalJSON is compacted
+	type Numbers struct {
+		Big   int64
+		UBig  uint64
+		F32   float32
+		F64   float64
+		Raw   leptRawMarshaler
+		Items []interface{}
+	}
+	input := Numbers{
+		Big: math.MaxInt64, UBig: math.MaxUint64, F32: 0.1, F64: 0.1,
+		Raw:   leptRawMarshaler{"{ \"a\" : [ 1.50 , true ] }"},
+		Items: []interface{}{int8(-1), "s", nil, map[string]float64{"z": 1e21, "a": 5e-324}},
+	}
+	buf, err := Marshal(input)
+	if err != nil {
+		t.Errorf("Marshal expect no err: %v", err)
+	}
+	ebuf, err := json.Marshal(input)
+	if err != nil {
+		t.Errorf("json.Marshal expect no err: %v", err)
+	}
+	expectEQString(t, string(ebuf), string(buf))
+
+	// the invalid json of MarshalJSON and the non-finite numbers are errors
+	for _, input := range []interface{}{leptRawMarshaler{"{"}, math.NaN(), []float32{float32(math.Inf(1))}} {
+		if buf, err := Marshal(input); err == nil {
+			t.Errorf("Marshal %v expect err, actual: %q", input, buf)
+		}
+	}
+}
+func TestMarshalUnsupported(t *testing.T) {
+	type Holder struct {
+		F func()
+	}
+	for _, input := range []interface{}{make(chan int), func() {}, complex(1, 2), Holder{}, []interface{}{1, make(chan int)}} {
+		buf, err := Marshal(input)
+		if err == nil || buf != nil {
+			t.Errorf("Marshal %T expect err, actual: %q", input, buf)
+		}
+	}
+}
 func TestMarshal(t *testing.T) {
 	type SubStruct struct {
 		T *bool          `json:"T"`
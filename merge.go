@@ -0,0 +1,36 @@
+package goleptjson
+
+import (
+	"fmt"
+)
+
+// leptMergeObject deep merge the members of src into the object dst,
+// nested objects are merged recursively, other values of src replace the ones of dst by copy
+func leptMergeObject(dst, src *LeptValue) {
+	for _, member := range src.o {
+		value := LeptSetObjectValue(dst, member.key)
+		if value.typ == LeptObject && member.value.typ == LeptObject {
+			leptMergeObject(value, member.value)
+			continue
+		}
+		LeptFree(value)
+		LeptCopy(value, member.value)
+	}
+}
+
+// LeptMergeObjects deep merge the objects of arr from left to right into a new object,
+// the later value wins
+func LeptMergeObjects(arr *LeptValue) (*LeptValue, error) {
+	if arr == nil || arr.typ != LeptArray {
+		return nil, fmt.Errorf("arr LeptValue is not a array")
+	}
+	ret := NewLeptValue()
+	LeptSetObject(ret)
+	for i, e := range arr.a {
+		if e.typ != LeptObject {
+			return nil, fmt.Errorf("arr LeptValue element %v is not a object: %v", i, e.typ)
+		}
+		leptMergeObject(ret, e)
+	}
+	return ret, nil
+}
@@ -0,0 +1,54 @@
+package goleptjson
+
+import (
+	"testing"
+)
+
+func TestLeptMergeObjects(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"[]", "{}"},
+		{"[{}]", "{}"},
+		{"[{\"a\":1}, {\"b\":2}]", "{\"a\":1,\"b\":2}"},
+		{"[{\"a\":1,\"b\":2}, {\"a\":3}, {\"a\":4}]", "{\"a\":4,\"b\":2}"},
+		{"[{\"db\":{\"host\":\"a\",\"port\":1}}, {\"db\":{\"port\":2,\"user\":\"u\"}}]", "{\"db\":{\"host\":\"a\",\"port\":2,\"user\":\"u\"}}"},
+		{"[{\"a\":[1,2]}, {\"a\":[3]}]", "{\"a\":[3]}"},
+		{"[{\"a\":{\"b\":1}}, {\"a\":null}, {\"a\":{\"c\":2}}]", "{\"a\":{\"c\":2}}"},
+		{"[{\"a\":1}, {\"a\":{\"b\":true}}]", "{\"a\":{\"b\":true}}"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		origin := LeptStringify(v)
+		ret, err := LeptMergeObjects(v)
+		if err != nil {
+			t.Errorf("LeptMergeObjects %v expect no err: %v", c.input, err)
+			continue
+		}
+		expect := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(expect, c.expect))
+		if !LeptIsEqual(expect, ret) {
+			t.Errorf("LeptMergeObjects %v expect: %v, actual: %v", c.input, c.expect, LeptStringify(ret))
+		}
+		// the fragments are not changed
+		expectEQString(t, origin, LeptStringify(v))
+	}
+
+	invalid := []struct {
+		input string
+	}{
+		{"{}"},
+		{"null"},
+		{"[{}, 1]"},
+		{"[{\"a\":1}, []]"},
+	}
+	for _, c := range invalid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		if _, err := LeptMergeObjects(v); err == nil {
+			t.Errorf("LeptMergeObjects %v should have err", c.input)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package goleptjson
+
+import (
+	"math"
+)
+
+// LeptParseOptions hold the options of LeptParseWithOptions, nil means the default options
+type LeptParseOptions struct {
+	// InternSmallIntegers share the immutable LeptValue of the integers in [0, 255]
+	// among the elements of array and object, the accessors like LeptGetArrayElement, LeptGetObjectValue
+	// and LeptSetObjectValue replace a shared value by a mutable copy before returning it,
+	// it is off while KeepNumberText or the layout is recorded as they need a value per token
+	InternSmallIntegers bool
+	// BMPOnly reject the code point above U+FFFF in string,
+	// both from surrogate pair and raw utf8, with LeptParseAstralChar
+	BMPOnly bool
+	// ValidateUTF8 reject the invalid utf8 sequence in string with LeptParseInvalidUTF8,
+	// the offset of LeptError points to the first byte of the sequence
+	ValidateUTF8 bool
+	// NumberParser convert the number token delimited by the grammar scanner,
+	// an error fails the parsing with LeptParseInvalidValue, nil means strconv.ParseFloat
+	NumberParser func(literal string) (float64, error)
+	// MaxExponent reject the number whose exponent magnitude exceeds it like 1e9999
+	// with LeptParseExponentTooLarge, 0 means unlimited, the default
+	MaxExponent int
+	// SurrogatePolicy decide how to handle the lone or malformed surrogate escape like "\uD800"
+	SurrogatePolicy LeptSurrogatePolicy
+	// BestEffort keep the arrays and objects parsed before the failure in v,
+	// so that a truncated document still gives a partial tree along with the event
+	BestEffort bool
+	// DisallowTrailingWhitespace reject any whitespace after the root value with LeptParseRootNotSingular,
+	// the offset of LeptError points to the first whitespace
+	DisallowTrailingWhitespace bool
+	// DisallowDuplicateKeys reject the key appearing twice in an object with LeptParseDuplicateKey,
+	// the offset of LeptError points to the second one
+	DisallowDuplicateKeys bool
+	// MaxDepth reject the arrays and objects nested deeper than it with LeptParseMaxDepth,
+	// so that a hostile input like a million [ cannot exhaust the stack, 0 means unlimited
+	MaxDepth int
+	// AllowComments skip the // line comments and /* block */ comments wherever whitespace is allowed,
+	// an unterminated block comment is left for the grammar to reject
+	AllowComments bool
+	// AllowTrailingComma accept a comma after the last element of array or member of object like [1,2,] and {"a":1,},
+	// a leading or doubled comma is still rejected
+	AllowTrailingComma bool
+	// KeepNumberText keep the number token as written like 1.0 and 1e3 on the value,
+	// LeptStringify writes it verbatim until the number is set again, LeptStringifyWithOptions formats by its options
+	KeepNumberText bool
+	// AllowNonFinite accept NaN, Infinity and -Infinity as numbers, which json forbids,
+	// LeptStringifyOptions has the same option to write them back
+	AllowNonFinite bool
+	// AllowHexNumbers accept the 0x prefixed hex integer like 0x1F as a number up to 0xFFFFFFFFFFFFFFFF,
+	// a bigger one fails with LeptParseNumberTooBig
+	AllowHexNumbers bool
+}
+
+// LeptSurrogatePolicy the handling of lone or malformed surrogate escape
+type LeptSurrogatePolicy int
+
+const (
+	// LeptSurrogateStrict reject it with LeptParseInvalidUnicodeSurrogate, the default
+	LeptSurrogateStrict LeptSurrogatePolicy = iota
+	// LeptSurrogateReplace decode it as U+FFFD
+	LeptSurrogateReplace
+	// LeptSurrogatePassthrough encode it as WTF-8 so that it round trips
+	LeptSurrogatePassthrough
+)
+
+// LeptDefaultMaxDepth the default MaxDepth
+const LeptDefaultMaxDepth = 1000
+
+var defaultLeptParseOptions = LeptParseOptions{
+	MaxDepth: LeptDefaultMaxDepth,
+}
+
+// NewLeptParseOptions return the default LeptParseOptions
+func NewLeptParseOptions() *LeptParseOptions {
+	opts := defaultLeptParseOptions
+	return &opts
+}
+
+// LeptParseWithOptions use to parse value with the options
+func LeptParseWithOptions(v *LeptValue, json string, opts *LeptParseOptions) LeptEvent {
+	if v == nil {
+		panic("LeptParseWithOptions v is nil")
+	}
+	c := NewLeptContext(json)
+	if opts != nil {
+		c.opts = opts
+	}
+	return leptParse(c, v)
+}
+
+// LeptParseErrWithOptions use to parse value with the options, report the failure as *LeptError
+func LeptParseErrWithOptions(v *LeptValue, json string, opts *LeptParseOptions) error {
+	if v == nil {
+		panic("LeptParseErrWithOptions v is nil")
+	}
+	c := NewLeptContext(json)
+	if opts != nil {
+		c.opts = opts
+	}
+	if event := leptParse(c, v); event != LeptParseOK {
+		return &LeptError{Event: event, Offset: leptOffset(c)}
+	}
+	return nil
+}
+
+// leptSmallIntegers the interned integers
+var leptSmallIntegers = func() []*LeptValue {
+	values := make([]*LeptValue, 256)
+	for i := range values {
+		values[i] = &LeptValue{typ: LeptNumber, n: float64(i), shared: true}
+	}
+	return values
+}()
+
+// leptInternNumber return the interned value and the rest of input if input starts with a small integer
+func leptInternNumber(input string) (*LeptValue, string) {
+	// only the plain integer literals, the others go through LeptParseNumber
+	end := 0
+	for end < len(input) && isDigit(input[end]) {
+		end++
+	}
+	if end < len(input) && (input[end] == '.' || input[end] == 'e' || input[end] == 'E') {
+		return nil, input
+	}
+	n, rest, err := strToFloat64(input)
+	if err != nil || n != math.Trunc(n) || n < 0 || n >= float64(len(leptSmallIntegers)) {
+		return nil, input
+	}
+	return leptSmallIntegers[int(n)], rest
+}
+
+// leptUnshare replace the shared interned value in slot by a mutable copy, and return the value in slot
+func leptUnshare(slot **LeptValue) *LeptValue {
+	if (*slot).shared {
+		value := NewLeptValue()
+		LeptCopy(value, *slot)
+		*slot = value
+	}
+	return *slot
+}
+
+// leptCheckMutable panic if v is a shared interned value
+func leptCheckMutable(v *LeptValue, name string) {
+	if v != nil && v.shared {
+		panic(name + " v is a shared interned value, copy it first")
+	}
+}
@@ -0,0 +1,678 @@
+package goleptjson
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLeptParseInternSmallIntegers(t *testing.T) {
+	input := "[0, 1, 255, 256, 1.5, -1, 1, {\"a\":1,\"b\":1.0}]"
+	opts := NewLeptParseOptions()
+	opts.InternSmallIntegers = true
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, input, opts))
+	expectEQString(t, "[0,1,255,256,1.5,-1,1,{\"a\":1,\"b\":1}]", LeptStringify(v))
+	expectEQBool(t, true, v.a[1] == v.a[6])
+	expectEQBool(t, false, v.a[3].shared)
+	expectEQBool(t, false, v.a[5].shared)
+	shared := v.a[1]
+
+	o := v.a[7]
+	expectEQBool(t, true, o.o[0].value == shared)
+	// set a shared value via LeptSetObjectValue copy it first
+	LeptSetNumber(LeptSetObjectValue(o, "a"), 2)
+	expectEQFloat64(t, 2, LeptGetNumber(LeptFindObjectValue(o, "a")))
+	expectEQFloat64(t, 1, LeptGetNumber(shared))
+	expectEQFloat64(t, 1, LeptGetNumber(LeptFindObjectValue(o, "b")))
+
+	// copy of a shared value is mutable
+	c := NewLeptValue()
+	LeptCopy(c, v)
+	LeptSetNumber(LeptGetArrayElement(c, 1), 3)
+	expectEQFloat64(t, 1, LeptGetNumber(shared))
+
+	// the accessors copy on write, so the element can be set, parsed or moved into
+	LeptSetNumber(LeptGetArrayElement(v, 1), 3)
+	expectEQBool(t, false, LeptGetArrayElement(v, 1) == shared)
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(LeptGetArrayElement(v, 6), "[4]"))
+	LeptMove(LeptGetArrayElement(v, 0), c)
+	expectEQFloat64(t, 1, LeptGetNumber(shared))
+	expectEQString(t, "[[0,3,255,256,1.5,-1,1,{\"a\":2,\"b\":1}],3,255,256,1.5,-1,[4],{\"a\":2,\"b\":1}]", LeptStringify(v))
+	w := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(w, "[1, 1]", opts))
+	p, err := LeptResolvePointer(w, "/1")
+	if err != nil {
+		t.Errorf("LeptResolvePointer expect no err: %v", err)
+	}
+	LeptSetString(p, "x")
+	expectEQString(t, "[1,\"x\"]", LeptStringify(w))
+	expectEQFloat64(t, 1, LeptGetNumber(shared))
+	expectPanic(t, "LeptSetNumber on shared value", func() { LeptSetNumber(shared, 3) })
+
+	// every token needs its own value for KeepNumberText and the layout
+	opts.KeepNumberText = true
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "[1, 1.0, 1]", opts))
+	expectEQBool(t, false, v.a[0] == v.a[2])
+	opts.KeepNumberText = false
+	event, layout := LeptParseWithLayout(v, "[ 1 , 1 ]", opts)
+	expectEQLeptEvent(t, LeptParseOK, event)
+	expectEQBool(t, false, v.a[0] == v.a[1])
+	LeptSetNumber(LeptGetArrayElement(v, 0), 2)
+	expectEQString(t, "[ 2 , 1 ]", LeptStringifyWithLayout(v, layout))
+
+	// default options do not intern
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, input, nil))
+	expectEQBool(t, false, v.a[1] == v.a[6])
+}
+
+func smallIntegersJSON() string {
+	items := make([]string, 100000)
+	for i := range items {
+		items[i] = strconv.Itoa(i % 200)
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+func BenchmarkParseSmallIntegers(b *testing.B) {
+	input := smallIntegersJSON()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := NewLeptValue()
+		if event := LeptParseWithOptions(v, input, nil); event != LeptParseOK {
+			b.Errorf("benchmark parse err : %v", event)
+		}
+	}
+}
+func BenchmarkParseSmallIntegersInterned(b *testing.B) {
+	input := smallIntegersJSON()
+	opts := NewLeptParseOptions()
+	opts.InternSmallIntegers = true
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := NewLeptValue()
+		if event := LeptParseWithOptions(v, input, opts); event != LeptParseOK {
+			b.Errorf("benchmark parse err : %v", event)
+		}
+	}
+}
+func TestLeptParseBMPOnly(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"\"\\uD83D\\uDE00\"", "\xF0\x9F\x98\x80"},
+		{"\"\xF0\x9F\x98\x80\"", "\xF0\x9F\x98\x80"},
+		{"[\"a\xF0\x9F\x98\x80b\"]", "a\xF0\x9F\x98\x80b"},
+	}
+	bmp := NewLeptParseOptions()
+	bmp.BMPOnly = true
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, nil))
+		if LeptGetType(v) == LeptArray {
+			v = LeptGetArrayElement(v, 0)
+		}
+		expectEQString(t, c.expect, LeptGetString(v))
+		expectEQLeptEvent(t, LeptParseAstralChar, LeptParseWithOptions(NewLeptValue(), c.input, bmp))
+	}
+	// BMP chars are still allowed
+	bmpValid := []struct {
+		input  string
+		expect string
+	}{
+		{"\"\\u20AC\"", "\xE2\x82\xAC"},
+		{"\"\xE2\x82\xAC\"", "\xE2\x82\xAC"},
+		{"\"\\uFFFF\"", "\xEF\xBF\xBF"},
+		// the invalid bytes are not astral, they are kept as is without ValidateUTF8
+		{"\"\xF8\xFF\"", "\xF8\xFF"},
+		{"\"\xF0\x9F\"", "\xF0\x9F"},
+	}
+	for _, c := range bmpValid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, bmp))
+		expectEQString(t, c.expect, LeptGetString(v))
+	}
+}
+func TestLeptParseNumberParser(t *testing.T) {
+	// parse the decimal with at most 2 fraction digits into cents
+	opts := NewLeptParseOptions()
+	opts.NumberParser = func(literal string) (float64, error) {
+		r, ok := new(big.Rat).SetString(literal)
+		if !ok {
+			return 0, fmt.Errorf("illegal decimal %v", literal)
+		}
+		r.Mul(r, big.NewRat(100, 1))
+		if !r.IsInt() {
+			return 0, fmt.Errorf("decimal %v has more than 2 fraction digits", literal)
+		}
+		f, _ := r.Float64()
+		return f, nil
+	}
+	valid := []struct {
+		input  string
+		expect float64
+	}{
+		{"0", 0},
+		{"12.34", 1234},
+		{"-0.1", -10},
+		{"1e2", 10000},
+		{"1.5E-1", 15},
+		{"123456789012345.67", 12345678901234567},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, opts))
+		expectEQFloat64(t, c.expect, LeptGetNumber(v))
+	}
+	invalid := []string{"1.234", "1e-3", "0123", "1.", "-"}
+	for _, input := range invalid {
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptParseWithOptions(NewLeptValue(), input, opts))
+	}
+	// the parser only get the number token
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "[1.1,{\"a\":2}]", opts))
+	expectEQString(t, "[110,{\"a\":200}]", LeptStringify(v))
+}
+
+func TestLeptParseValidateUTF8(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.ValidateUTF8 = true
+	invalid := []struct {
+		input  string
+		offset int
+	}{
+		{"\"\xFF\"", 1},
+		{"\"abc\x80\"", 4},
+		{"[\"ok\", \"\xE2\x82\xAC\xE2\x82\"]", 11},
+		{"{\"a\":1, \"b\xC0\xAF\":2}", 10},
+		{"\"\xED\xA0\x80\"", 1},
+	}
+	for _, c := range invalid {
+		// the invalid bytes are kept without validation
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(NewLeptValue(), c.input, nil))
+		err := LeptParseErrWithOptions(NewLeptValue(), c.input, opts)
+		e, ok := err.(*LeptError)
+		if !ok {
+			t.Errorf("LeptParseErrWithOptions %q expect *LeptError, actual: %v", c.input, err)
+			continue
+		}
+		expectEQLeptEvent(t, LeptParseInvalidUTF8, e.Event)
+		expectEQInt(t, c.offset, e.Offset)
+	}
+	valid := []string{"\"\xE2\x82\xAC\"", "\"\xF0\x9F\x98\x80\"", "\"\\uD83D\\uDE00\"", "\"abc\""}
+	for _, input := range valid {
+		v := NewLeptValue()
+		if err := LeptParseErrWithOptions(v, input, opts); err != nil {
+			t.Errorf("LeptParseErrWithOptions %q expect no err: %v", input, err)
+		}
+	}
+	opts.BMPOnly = true
+	expectEQLeptEvent(t, LeptParseAstralChar, LeptParseWithOptions(NewLeptValue(), "\"\xF0\x9F\x98\x80\"", opts))
+}
+
+func TestLeptParseMaxExponent(t *testing.T) {
+	expectEQInt(t, 0, NewLeptParseOptions().MaxExponent)
+	opts := NewLeptParseOptions()
+	opts.MaxExponent = 300
+	valid := []struct {
+		input  string
+		expect float64
+	}{
+		{"1e300", 1e300},
+		{"1E+300", 1e300},
+		{"1e-300", 1e-300},
+		{"1.5e0000300", 1.5e300},
+		{"123456", 123456},
+		{"0.5", 0.5},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, opts))
+		expectEQFloat64(t, c.expect, LeptGetNumber(v))
+	}
+	invalid := []string{"1e301", "1E-301", "-2.5e+301", "1e99999999999999999999", "[0, 1e301]", "{\"a\":1e-301}"}
+	for _, input := range invalid {
+		expectEQLeptEvent(t, LeptParseExponentTooLarge, LeptParseWithOptions(NewLeptValue(), input, opts))
+	}
+	// the interned path is checked too
+	opts.InternSmallIntegers = true
+	expectEQLeptEvent(t, LeptParseExponentTooLarge, LeptParseWithOptions(NewLeptValue(), "[0e-301]", opts))
+
+	// the default is unlimited, the underflow and zero are fine and the overflow is too big
+	for _, input := range []string{"1e-10001", "0e10001", "0.0e-99999", "-1e-99999999999999999999"} {
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(NewLeptValue(), input))
+		expectEQLeptEvent(t, LeptParseOK, LeptValidate(input))
+	}
+	expectEQLeptEvent(t, LeptParseNumberTooBig, LeptParse(NewLeptValue(), "1e10001"))
+	expectEQLeptEvent(t, LeptParseNumberTooBig, LeptValidate("1e9999999"))
+	opts.MaxExponent = 0
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(NewLeptValue(), "1e-99999", opts))
+}
+
+func TestLeptParseSurrogatePolicy(t *testing.T) {
+	valid := []struct {
+		input       string
+		replace     string
+		passthrough string
+	}{
+		{"\"\\uD800\"", "\xEF\xBF\xBD", "\xED\xA0\x80"},
+		{"\"a\\uDBFFb\"", "a\xEF\xBF\xBDb", "a\xED\xAF\xBFb"},
+		{"\"\\uDC00\"", "\xEF\xBF\xBD", "\xED\xB0\x80"},
+		{"\"\\uD800\\\\\"", "\xEF\xBF\xBD\\", "\xED\xA0\x80\\"},
+		{"\"\\uD800\\uE000\"", "\xEF\xBF\xBD\xEE\x80\x80", "\xED\xA0\x80\xEE\x80\x80"},
+		{"\"\\uD800\\uD83D\\uDE00\"", "\xEF\xBF\xBD\xF0\x9F\x98\x80", "\xED\xA0\x80\xF0\x9F\x98\x80"},
+	}
+	opts := NewLeptParseOptions()
+	for _, c := range valid {
+		opts.SurrogatePolicy = LeptSurrogateStrict
+		expectEQLeptEvent(t, LeptParseInvalidUnicodeSurrogate, LeptParseWithOptions(NewLeptValue(), c.input, opts))
+
+		v := NewLeptValue()
+		opts.SurrogatePolicy = LeptSurrogateReplace
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, opts))
+		expectEQString(t, c.replace, LeptGetString(v))
+
+		opts.SurrogatePolicy = LeptSurrogatePassthrough
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, opts))
+		expectEQString(t, c.passthrough, LeptGetString(v))
+		// round trip
+		v2 := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v2, LeptStringify(v), opts))
+		expectEQString(t, c.passthrough, LeptGetString(v2))
+	}
+	// the valid pair is not affected
+	for _, policy := range []LeptSurrogatePolicy{LeptSurrogateStrict, LeptSurrogateReplace, LeptSurrogatePassthrough} {
+		v := NewLeptValue()
+		opts.SurrogatePolicy = policy
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "\"\\uD834\\uDD1E\"", opts))
+		expectEQString(t, "\xF0\x9D\x84\x9E", LeptGetString(v))
+	}
+}
+
+func TestLeptParseBestEffort(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.BestEffort = true
+	valid := []struct {
+		input  string
+		event  LeptEvent
+		expect string
+	}{
+		{"[1, 2, [3, 4", LeptParseMissCommaOrSouareBracket, "[1,2,[3,4]]"},
+		{"[1, 2, tru", LeptParseInvalidValue, "[1,2]"},
+		{"[", LeptParseMissCommaOrSouareBracket, "[]"},
+		{"{\"a\":1,\"b\":{\"c\":[true, {\"d\":\"x\"", LeptParseMissCommaOrCurlyBracket, "{\"a\":1,\"b\":{\"c\":[true,{\"d\":\"x\"}]}}"},
+		{"{\"a\":1,\"b\":\"unterminated", LeptParseMissQuotationMark, "{\"a\":1}"},
+		{"{\"a\":1,\"b\"", LeptParseMissColon, "{\"a\":1}"},
+		{"{\"a\":1,", LeptParseMissKey, "{\"a\":1}"},
+		{"{\"a\":[1] \"b\":2}", LeptParseMissCommaOrCurlyBracket, "{\"a\":[1]}"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, c.event, LeptParseWithOptions(v, c.input, opts))
+		expectEQString(t, c.expect, LeptStringify(v))
+
+		// strict by default
+		v = NewLeptValue()
+		expectEQLeptEvent(t, c.event, LeptParseWithOptions(v, c.input, nil))
+		expectEQLeptType(t, LeptNull, LeptGetType(v))
+	}
+}
+
+func TestLeptParseDisallowTrailingWhitespace(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.DisallowTrailingWhitespace = true
+	valid := []struct {
+		input  string
+		offset int
+	}{
+		{"{}  ", 2},
+		{"{}\n", 2},
+		{"[1, 2]\r\n", 6},
+		{" 1\t", 2},
+		{"\"a\" ", 3},
+	}
+	for _, c := range valid {
+		expectEQLeptEvent(t, LeptParseRootNotSingular, LeptParseWithOptions(NewLeptValue(), c.input, opts))
+		err := LeptParseErrWithOptions(NewLeptValue(), c.input, opts)
+		if e, ok := err.(*LeptError); !ok || e.Offset != c.offset {
+			t.Errorf("expect offset %d, actual %v", c.offset, err)
+		}
+
+		// permitted by default
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(NewLeptValue(), c.input, nil))
+	}
+	// the leading whitespace and the whitespace inside the value are not affected
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "\n { \"a\" : [ 1 , 2 ] }", opts))
+	expectEQString(t, "{\"a\":[1,2]}", LeptStringify(v))
+}
+
+func TestLeptParseDisallowDuplicateKeys(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.DisallowDuplicateKeys = true
+	invalid := []struct {
+		input  string
+		offset int
+	}{
+		{"{\"a\":1,\"a\":2}", 7},
+		{"{\"a\":1, \"b\":2, \"a\":3}", 15},
+		{"[{\"x\":{\"\":1,\"\":1}}]", 12},
+		{"{\"\\u0061\":1,\"a\":2}", 12},
+	}
+	for _, c := range invalid {
+		expectEQLeptEvent(t, LeptParseDuplicateKey, LeptParseWithOptions(NewLeptValue(), c.input, opts))
+		err := LeptParseErrWithOptions(NewLeptValue(), c.input, opts)
+		if e, ok := err.(*LeptError); !ok || e.Offset != c.offset {
+			t.Errorf("LeptParseErrWithOptions %q expect offset %d, actual: %v", c.input, c.offset, err)
+		}
+
+		// permitted by default
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(NewLeptValue(), c.input, nil))
+	}
+	// the same key in different objects is fine
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "{\"a\":{\"a\":1},\"b\":[{\"a\":1},{\"a\":2}]}", opts))
+	expectEQString(t, "goleptjson: duplicate key at offset 7", LeptParseErrWithOptions(NewLeptValue(), "{\"a\":1,\"a\":2}", opts).Error())
+}
+
+func TestLeptParseMaxDepth(t *testing.T) {
+	expectEQInt(t, LeptDefaultMaxDepth, NewLeptParseOptions().MaxDepth)
+	nested := func(open, close string, n int) string {
+		return strings.Repeat(open, n) + strings.Repeat(close, n)
+	}
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(NewLeptValue(), nested("[", "]", LeptDefaultMaxDepth)))
+	expectEQLeptEvent(t, LeptParseMaxDepth, LeptParse(NewLeptValue(), nested("[", "]", LeptDefaultMaxDepth+1)))
+	// a million open brackets fail fast without recursing
+	expectEQLeptEvent(t, LeptParseMaxDepth, LeptParse(NewLeptValue(), strings.Repeat("[", 1000000)))
+
+	opts := NewLeptParseOptions()
+	opts.MaxDepth = 3
+	invalid := []struct {
+		input  string
+		offset int
+	}{
+		{"[[[[]]]]", 3},
+		{"{\"a\":{\"b\":[{}]}}", 11},
+		{"[1,{\"a\":[2,[3]]}]", 11},
+	}
+	for _, c := range invalid {
+		expectEQLeptEvent(t, LeptParseMaxDepth, LeptParseWithOptions(NewLeptValue(), c.input, opts))
+		err := LeptParseErrWithOptions(NewLeptValue(), c.input, opts)
+		if e, ok := err.(*LeptError); !ok || e.Offset != c.offset {
+			t.Errorf("LeptParseErrWithOptions %q expect offset %d, actual: %v", c.input, c.offset, err)
+		}
+	}
+	// the siblings do not add up
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "[[[]],[[]],{\"a\":[]}]", opts))
+	expectEQInt(t, 3, LeptValueDepth(v))
+	opts.MaxDepth = 0
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(NewLeptValue(), strings.Repeat("{\"a\":", 2000)+"0"+strings.Repeat("}", 2000), opts))
+	expectEQString(t, "goleptjson: max depth exceeded at offset 3", LeptParseErrWithOptions(NewLeptValue(), "[[[[]]]]", &LeptParseOptions{MaxDepth: 3}).Error())
+}
+
+func TestLeptParseAllowComments(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.AllowComments = true
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"// before\n[1]", "[1]"},
+		{"/* before */ 1", "1"},
+		{"/**/1/**/", "1"},
+		{"[1, /* between */ 2 // line\n]", "[1,2]"},
+		{"[1, // c\r2]", "[1,2]"},
+		{"[1, // c\r\n2]", "[1,2]"},
+		{"{ /* k */ \"a\" /* : */ : // v\n 1 /* , */ , \"b\" : [ ] }", "{\"a\":1,\"b\":[]}"},
+		{"[1] // after", "[1]"},
+		{"[1] /* after */ \n // and more\n", "[1]"},
+		{"1//", "1"},
+		{"/* ** / * */ true", "true"},
+		{"\"/* kept */ // kept\"", "\"/* kept */ // kept\""},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, opts))
+		expectEQString(t, c.expect, LeptStringify(v))
+	}
+	invalid := []struct {
+		input  string
+		allow  LeptEvent
+		strict LeptEvent
+	}{
+		{"// c\n1", LeptParseOK, LeptParseInvalidValue},
+		{"[1 /* c */]", LeptParseOK, LeptParseMissCommaOrSouareBracket},
+		{"{\"a\":1 // c\n}", LeptParseOK, LeptParseMissCommaOrCurlyBracket},
+		{"1 // c", LeptParseOK, LeptParseRootNotSingular},
+		{"/ 1", LeptParseInvalidValue, LeptParseInvalidValue},
+		{"[1,/]", LeptParseInvalidValue, LeptParseInvalidValue},
+		{"[1 /* unterminated ]", LeptParseMissCommaOrSouareBracket, LeptParseMissCommaOrSouareBracket},
+		{"1 /* unterminated", LeptParseRootNotSingular, LeptParseRootNotSingular},
+	}
+	for _, c := range invalid {
+		expectEQLeptEvent(t, c.allow, LeptParseWithOptions(NewLeptValue(), c.input, opts))
+		expectEQLeptEvent(t, c.strict, LeptParseWithOptions(NewLeptValue(), c.input, nil))
+	}
+	// the newlines in comments count for the error position
+	c := NewLeptContext("/* a\n b */ [1, // c\n  x]")
+	c.opts = opts
+	expectEQLeptEvent(t, LeptParseInvalidValue, leptParse(c, NewLeptValue()))
+	line, col := LeptGetErrorPosition(c)
+	expectEQInt(t, 3, line)
+	expectEQInt(t, 3, col)
+	// so do a lone \r and \r\n, which is one line break
+	c = NewLeptContext("/* a\r b\r\n c */ [1, // c\r  x]")
+	c.opts = opts
+	expectEQLeptEvent(t, LeptParseInvalidValue, leptParse(c, NewLeptValue()))
+	line, col = LeptGetErrorPosition(c)
+	expectEQInt(t, 4, line)
+	expectEQInt(t, 3, col)
+	// the offset of an unterminated block comment points to it
+	err := LeptParseErrWithOptions(NewLeptValue(), "[1 /* x", opts)
+	expectEQString(t, "goleptjson: miss comma or square bracket at offset 3", err.Error())
+}
+
+func TestLeptParseAllowTrailingComma(t *testing.T) {
+	allow := NewLeptParseOptions()
+	allow.AllowTrailingComma = true
+	cases := []struct {
+		input  string
+		strict LeptEvent
+		allow  LeptEvent
+		expect string
+	}{
+		{"[1,2,]", LeptParseInvalidValue, LeptParseOK, "[1,2]"},
+		{"[ 1 , [ 2 , ] , ]", LeptParseInvalidValue, LeptParseOK, "[1,[2]]"},
+		{"{\"a\":1,}", LeptParseMissKey, LeptParseOK, "{\"a\":1}"},
+		{"{ \"a\" : { \"b\" : [ 1 , ] , } , }", LeptParseInvalidValue, LeptParseOK, "{\"a\":{\"b\":[1]}}"},
+		{"[1,2]", LeptParseOK, LeptParseOK, "[1,2]"},
+		{"{\"a\":1}", LeptParseOK, LeptParseOK, "{\"a\":1}"},
+		// only the comma before the close is relaxed
+		{"[,]", LeptParseInvalidValue, LeptParseInvalidValue, ""},
+		{"[,1]", LeptParseInvalidValue, LeptParseInvalidValue, ""},
+		{"[1,,]", LeptParseInvalidValue, LeptParseInvalidValue, ""},
+		{"{,}", LeptParseMissKey, LeptParseMissKey, ""},
+		{"{\"a\":1,,}", LeptParseMissKey, LeptParseMissKey, ""},
+		{"[1,", LeptParseExpectValue, LeptParseExpectValue, ""},
+		{"{\"a\":1,", LeptParseMissKey, LeptParseMissKey, ""},
+		{"1,", LeptParseRootNotSingular, LeptParseRootNotSingular, ""},
+	}
+	for _, c := range cases {
+		expectEQLeptEvent(t, c.strict, LeptParseWithOptions(NewLeptValue(), c.input, nil))
+		v := NewLeptValue()
+		expectEQLeptEvent(t, c.allow, LeptParseWithOptions(v, c.input, allow))
+		if c.allow == LeptParseOK {
+			expectEQString(t, c.expect, LeptStringify(v))
+		}
+	}
+	// works along with comments
+	allow.AllowComments = true
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "[1, // last\n]", allow))
+	expectEQString(t, "[1]", LeptStringify(v))
+}
+
+func TestLeptParseKeepNumberText(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.KeepNumberText = true
+	for _, input := range []string{"1.0", "1e3", "100", "-0.0", "1E+03", "0.10", "123456789012345678901"} {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, input, opts))
+		expectEQString(t, input, LeptGetNumberText(v))
+		expectEQString(t, input, LeptStringify(v))
+	}
+	v := NewLeptValue()
+	input := "{\"version\":1.0,\"size\":1e3,\"list\":[100,2.50]}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, input, opts))
+	expectEQString(t, input, LeptStringify(v))
+	// the options of LeptStringifyWithOptions decide the form
+	expectEQString(t, "{\"version\":1,\"size\":1000,\"list\":[100,2.5]}", LeptStringifyWithOptions(v, nil))
+	// the text follows copy, and is dropped once the number is set
+	c := NewLeptValue()
+	LeptCopy(c, LeptGetObjectValue(v, 0))
+	expectEQString(t, "1.0", LeptStringify(c))
+	LeptSetNumber(c, 2)
+	expectEQString(t, "2", LeptGetNumberText(c))
+	expectEQString(t, "2", LeptStringify(c))
+	LeptMapNumbers(v, func(n float64) float64 { return n })
+	expectEQString(t, "{\"version\":1,\"size\":1000,\"list\":[100,2.5]}", LeptStringify(v))
+
+	// the text is dropped when v is parsed again
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "1.50", opts))
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "2"))
+	expectEQString(t, "2", LeptStringify(v))
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "1.50", opts))
+	// so does LeptParseNumber alone
+	c = NewLeptValue()
+	LeptCopy(c, v)
+	expectEQLeptEvent(t, LeptParseOK, LeptParseNumber(NewLeptContext("3.0"), c))
+	expectEQString(t, "3", LeptStringify(c))
+
+	// the default stays numeric
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "1.0"))
+	expectEQString(t, "1", LeptGetNumberText(v))
+	expectEQString(t, "1", LeptStringify(v))
+	expectPanic(t, "LeptGetNumberText of null", func() { LeptGetNumberText(NewLeptValue()) })
+}
+
+func TestLeptParseAllowNonFinite(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.AllowNonFinite = true
+	sopts := NewLeptStringifyOptions()
+	sopts.AllowNonFinite = true
+	valid := []struct {
+		input string
+		check func(float64) bool
+	}{
+		{"NaN", math.IsNaN},
+		{"Infinity", func(n float64) bool { return math.IsInf(n, 1) }},
+		{"-Infinity", func(n float64) bool { return math.IsInf(n, -1) }},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, opts))
+		expectEQLeptType(t, LeptNumber, LeptGetType(v))
+		expectEQBool(t, true, c.check(LeptGetNumber(v)))
+		expectEQString(t, c.input, LeptStringifyWithOptions(v, sopts))
+		sopts.KeepDecimalPoint = true
+		expectEQString(t, c.input, LeptStringifyWithOptions(v, sopts))
+		sopts.KeepDecimalPoint = false
+
+		// invalid by default
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptParse(NewLeptValue(), c.input))
+		expectEQLeptEvent(t, LeptParseInvalidValue, LeptValidate(c.input))
+	}
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "[NaN, Infinity,-Infinity,{\"a\":-1.5}]", opts))
+	expectEQString(t, "[NaN,Infinity,-Infinity,{\"a\":-1.5}]", LeptStringifyWithOptions(v, sopts))
+	// the stringify option is needed to write them back as tokens, otherwise they are null
+	expectEQString(t, "[null,null,null,{\"a\":-1.5}]", LeptStringifyWithOptions(v, nil))
+	expectEQString(t, "[null,null,null,{\"a\":-1.5}]", LeptStringify(v))
+	w := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(w, LeptStringifyWithOptions(v, sopts), opts))
+	expectEQString(t, "[NaN,Infinity,-Infinity,{\"a\":-1.5}]", LeptStringifyWithOptions(w, sopts))
+
+	for _, input := range []string{"nan", "NAN", "Inf", "infinity", "+Infinity", "-NaN", "Infinityx", "[NaN1]"} {
+		expectEQBool(t, true, LeptParseWithOptions(NewLeptValue(), input, opts) != LeptParseOK)
+	}
+}
+
+func TestLeptParseAllowHexNumbers(t *testing.T) {
+	opts := NewLeptParseOptions()
+	opts.AllowHexNumbers = true
+	valid := []struct {
+		input  string
+		expect float64
+	}{
+		{"0xFF", 255},
+		{"0x0", 0},
+		{"0x1F", 31},
+		{"0X1f", 31},
+		{"0x00000010", 16},
+		{"0xFFFFFFFFFFFFFFFF", 18446744073709551615},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, c.input, opts))
+		expectEQFloat64(t, c.expect, LeptGetNumber(v))
+		// invalid by default, 0X stops after 0 as before
+		expectEQBool(t, true, LeptParse(NewLeptValue(), c.input) != LeptParseOK)
+	}
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "{\"mask\":[0x1, 0x10,16, 1.5e1]}", opts))
+	expectEQString(t, "{\"mask\":[1,16,16,15]}", LeptStringify(v))
+	opts.KeepNumberText = true
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "[0x1F]", opts))
+	expectEQString(t, "[0x1F]", LeptStringify(v))
+	opts.KeepNumberText = false
+
+	invalid := []struct {
+		input  string
+		expect LeptEvent
+	}{
+		{"0xG", LeptParseInvalidValue},
+		{"0x", LeptParseInvalidValue},
+		{"[0x]", LeptParseInvalidValue},
+		{"x1F", LeptParseInvalidValue},
+		{"-0x1F", LeptParseInvalidValue},
+		{"00x1F", LeptParseInvalidValue},
+		{"0x1G", LeptParseRootNotSingular},
+		{"0x1.5", LeptParseRootNotSingular},
+		{"0x10000000000000000", LeptParseNumberTooBig},
+	}
+	for _, c := range invalid {
+		expectEQLeptEvent(t, c.expect, LeptParseWithOptions(NewLeptValue(), c.input, opts))
+	}
+	// the hex above 2^53 which float64 can not hold is warned like the decimal
+	event, warnings := LeptParseWithWarnings(NewLeptValue(), "[0x20000000000000, 0x20000000000001, 0xFFFFFFFFFFFFFFFF]", opts)
+	expectEQLeptEvent(t, LeptParseOK, event)
+	expect := []LeptWarning{
+		{LeptWarningPrecisionLoss, 19, "0x20000000000001"},
+		{LeptWarningPrecisionLoss, 37, "0xFFFFFFFFFFFFFFFF"},
+	}
+	expectEQInt(t, len(expect), len(warnings))
+	for i := 0; i < len(expect) && i < len(warnings); i++ {
+		if expect[i] != warnings[i] {
+			t.Errorf("LeptParseWithWarnings hex, expect: %v, actual: %v", expect[i], warnings[i])
+		}
+	}
+	// the raw text is recorded in the layout
+	h := NewLeptValue()
+	event, layout := LeptParseWithLayout(h, "[ 0x1F ]", opts)
+	expectEQLeptEvent(t, LeptParseOK, event)
+	expectEQString(t, "0x1F", layout.node(LeptGetArrayElement(h, 0)).raw)
+
+	// the decimal numbers are untouched
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "0", opts))
+	expectEQLeptEvent(t, LeptParseInvalidValue, LeptParseWithOptions(v, "0123", opts))
+}
@@ -0,0 +1,237 @@
+package goleptjson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// leptPointerUnescaper decode the reference token of JSON Pointer, ~1 before ~0
+var leptPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// leptPointerTokens split the JSON Pointer (RFC 6901) into the decoded reference tokens
+func leptPointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("pointer %q does not start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 >= len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("pointer %q has invalid escape in %q", pointer, token)
+			}
+		}
+		tokens[i] = leptPointerUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+// leptResolvePointer return the value referred by the JSON Pointer, "" refers to v itself
+func leptResolvePointer(v *LeptValue, pointer string) (*LeptValue, error) {
+	tokens, err := leptPointerTokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+	for i, token := range tokens {
+		switch v.typ {
+		case LeptArray:
+			index, ok := leptArrayIndex(token)
+			if !ok {
+				return nil, fmt.Errorf("pointer %q: %q is not a array index", pointer, token)
+			}
+			if index >= len(v.a) {
+				return nil, fmt.Errorf("pointer %q: index %v out of range of array size %v", pointer, index, len(v.a))
+			}
+			v = leptUnshare(&v.a[index])
+		case LeptObject:
+			value := LeptFindObjectValue(v, token)
+			if value == nil {
+				return nil, fmt.Errorf("pointer %q: key %q not exist", pointer, token)
+			}
+			v = value
+		default:
+			return nil, fmt.Errorf("pointer %q: %v at /%v is not a array or object", pointer, v.typ, strings.Join(tokens[:i], "/"))
+		}
+	}
+	return v, nil
+}
+
+// LeptResolvePointer return the value referred by the JSON Pointer (RFC 6901) in v, "" refers to v itself,
+// the missing key, the array index out of range or not a number, and the invalid pointer are reported as error
+func LeptResolvePointer(v *LeptValue, pointer string) (*LeptValue, error) {
+	if v == nil {
+		panic("LeptResolvePointer v is nil")
+	}
+	return leptResolvePointer(v, pointer)
+}
+
+// LeptRequirePaths return the JSON Pointers which are missing from root in order, empty if all are present
+func LeptRequirePaths(root *LeptValue, pointers []string) []string {
+	if root == nil {
+		panic("LeptRequirePaths root is nil")
+	}
+	missing := make([]string, 0)
+	for _, pointer := range pointers {
+		if _, err := leptResolvePointer(root, pointer); err != nil {
+			missing = append(missing, pointer)
+		}
+	}
+	return missing
+}
+
+// leptResolveParent return the container holding the value referred by the JSON Pointer and the last token,
+// the value itself may not exist
+func leptResolveParent(v *LeptValue, pointer string) (*LeptValue, string, error) {
+	tokens, err := leptPointerTokens(pointer)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tokens) == 0 {
+		return nil, "", fmt.Errorf("pointer %q refers to the root which has no parent", pointer)
+	}
+	i := strings.LastIndexByte(pointer, '/')
+	parent, err := leptResolvePointer(v, pointer[:i])
+	if err != nil {
+		return nil, "", err
+	}
+	if parent.typ != LeptArray && parent.typ != LeptObject {
+		return nil, "", fmt.Errorf("pointer %q: parent %v is not a array or object", pointer, parent.typ)
+	}
+	return parent, tokens[len(tokens)-1], nil
+}
+
+// LeptIsEqualIgnoring check a b is equal like LeptIsEqual, except the values referred by ignorePointers,
+// the ignored object members are removed and the ignored array elements are masked as null in copies before comparison
+func LeptIsEqualIgnoring(a, b *LeptValue, ignorePointers []string) bool {
+	if a == nil || b == nil {
+		panic("LeptIsEqualIgnoring a or b is nil")
+	}
+	ca, cb := NewLeptValue(), NewLeptValue()
+	LeptCopy(ca, a)
+	LeptCopy(cb, b)
+	for _, pointer := range ignorePointers {
+		if pointer == "" {
+			return true
+		}
+		leptMaskPointer(ca, pointer)
+		leptMaskPointer(cb, pointer)
+	}
+	return LeptIsEqual(ca, cb)
+}
+
+func leptMaskPointer(v *LeptValue, pointer string) {
+	parent, token, err := leptResolveParent(v, pointer)
+	if err != nil {
+		return
+	}
+	if parent.typ == LeptObject {
+		members := make([]*LeptMember, 0, len(parent.o))
+		for _, member := range parent.o {
+			if member.key != token {
+				members = append(members, member)
+			}
+		}
+		parent.o = members
+		return
+	}
+	if index, ok := leptArrayIndex(token); ok && index < len(parent.a) {
+		parent.a[index] = NewLeptValue()
+	}
+}
+
+// LeptRedact return a copy of v with the values referred by pointers replaced by copies of replacement,
+// the missing pointers are skipped
+func LeptRedact(v *LeptValue, pointers []string, replacement *LeptValue) *LeptValue {
+	if v == nil || replacement == nil {
+		panic("LeptRedact v or replacement is nil")
+	}
+	ret := NewLeptValue()
+	LeptCopy(ret, v)
+	for _, pointer := range pointers {
+		node, err := leptResolvePointer(ret, pointer)
+		if err != nil {
+			continue
+		}
+		LeptFree(node)
+		LeptCopy(node, replacement)
+	}
+	return ret
+}
+
+// LeptSplice replace the value referred by the JSON Pointer with a deep copy of subtree,
+// the missing object members and null on the way are created as objects,
+// the array index equal to size or "-" appends to the array
+func LeptSplice(root *LeptValue, pointer string, subtree *LeptValue) error {
+	if root == nil || subtree == nil {
+		panic("LeptSplice root or subtree is nil")
+	}
+	tokens, err := leptPointerTokens(pointer)
+	if err != nil {
+		return err
+	}
+	// copy first, subtree may be a part of root
+	graft := NewLeptValue()
+	LeptCopy(graft, subtree)
+	if len(tokens) == 0 {
+		LeptMove(root, graft)
+		return nil
+	}
+	// walk the existing values first, every failure happens here so that root is unchanged on error
+	v := root
+	i := 0
+walk:
+	for ; i < len(tokens); i++ {
+		token, last := tokens[i], i == len(tokens)-1
+		switch v.typ {
+		case LeptNull:
+			break walk
+		case LeptObject:
+			index := LeptFindObjectIndex(v, token)
+			if index == LeptKeyNotExist {
+				break walk
+			}
+			if last {
+				v.o[index].value = graft
+				return nil
+			}
+			v = v.o[index].value
+		case LeptArray:
+			index, ok := len(v.a), token == "-"
+			if !ok {
+				index, ok = leptArrayIndex(token)
+			}
+			if !ok || index > len(v.a) {
+				return fmt.Errorf("pointer %q: %q is not a array index in range of size %v", pointer, token, len(v.a))
+			}
+			if index == len(v.a) {
+				break walk
+			}
+			if last {
+				v.a[index] = graft
+				return nil
+			}
+			v = v.a[index]
+		default:
+			return fmt.Errorf("pointer %q: %v at /%v is not a array or object", pointer, v.typ, strings.Join(tokens[:i], "/"))
+		}
+	}
+	// create the rest, v is null, the object missing the key or the array appended to
+	for ; i < len(tokens); i++ {
+		if v.typ == LeptNull {
+			LeptSetObject(v)
+		}
+		next := graft
+		if i < len(tokens)-1 {
+			next = NewLeptValue()
+		}
+		if v.typ == LeptObject {
+			v.o = append(v.o, &LeptMember{key: tokens[i], value: next})
+		} else {
+			v.a = append(v.a, next)
+		}
+		v = next
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package goleptjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLeptResolvePointer(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"foo\":[{\"bar\":1},{\"bar\":[true,null]}],\"a/b\":2,\"m~n\":3,\"\":4,\"~1\":5}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	valid := []struct {
+		pointer string
+		expect  string
+	}{
+		{"", input},
+		{"/foo", "[{\"bar\":1},{\"bar\":[true,null]}]"},
+		{"/foo/0/bar", "1"},
+		{"/foo/1/bar/1", "null"},
+		{"/a~1b", "2"},
+		{"/m~0n", "3"},
+		{"/", "4"},
+		{"/~01", "5"},
+	}
+	for _, c := range valid {
+		value, err := LeptResolvePointer(v, c.pointer)
+		if err != nil {
+			t.Errorf("LeptResolvePointer %q expect no err: %v", c.pointer, err)
+			continue
+		}
+		expectEQString(t, c.expect, LeptStringify(value))
+	}
+	// the whole document is v itself
+	if value, _ := LeptResolvePointer(v, ""); value != v {
+		t.Errorf("LeptResolvePointer \"\" expect the document itself")
+	}
+	invalid := []struct {
+		pointer string
+		expect  string
+	}{
+		{"/foo/2", "pointer \"/foo/2\": index 2 out of range of array size 2"},
+		{"/foo/-", "pointer \"/foo/-\": \"-\" is not a array index"},
+		{"/foo/01", "pointer \"/foo/01\": \"01\" is not a array index"},
+		{"/baz", "pointer \"/baz\": key \"baz\" not exist"},
+		{"/foo/0/bar/x", "pointer \"/foo/0/bar/x\": LeptNumber at /foo/0/bar is not a array or object"},
+		{"foo", "pointer \"foo\" does not start with /"},
+		{"/m~2n", "pointer \"/m~2n\" has invalid escape in \"m~2n\""},
+	}
+	for _, c := range invalid {
+		value, err := LeptResolvePointer(v, c.pointer)
+		if err == nil || value != nil {
+			t.Errorf("LeptResolvePointer %q expect err, actual: %v", c.pointer, value)
+			continue
+		}
+		expectEQString(t, c.expect, err.Error())
+	}
+}
+
+func TestLeptRequirePaths(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"user\":{\"name\":\"a\",\"emails\":[\"a@a.com\"],\"age\":null},\"a/b\":1,\"m~n\":2,\"\":3}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+
+	present := []string{"", "/user", "/user/name", "/user/emails/0", "/user/age", "/a~1b", "/m~0n", "/"}
+	if missing := LeptRequirePaths(v, present); missing == nil || len(missing) != 0 {
+		t.Errorf("LeptRequirePaths expect empty, actual: %v", missing)
+	}
+	pointers := []string{
+		"/user/name",
+		"/user/phone",
+		"/user/emails/1",
+		"/user/emails/-",
+		"/user/emails/01",
+		"/user/name/first",
+		"/a/b",
+		"/m~2n",
+		"user",
+		"/user/age",
+	}
+	expect := []string{"/user/phone", "/user/emails/1", "/user/emails/-", "/user/emails/01", "/user/name/first", "/a/b", "/m~2n", "user"}
+	if missing := LeptRequirePaths(v, pointers); !reflect.DeepEqual(expect, missing) {
+		t.Errorf("LeptRequirePaths expect: %v, actual: %v", expect, missing)
+	}
+}
+
+func TestLeptIsEqualIgnoring(t *testing.T) {
+	valid := []struct {
+		a      string
+		b      string
+		ignore []string
+		expect bool
+	}{
+		{"{\"id\":1,\"ts\":100,\"name\":\"a\"}", "{\"name\":\"a\",\"ts\":200,\"id\":1}", []string{"/ts"}, true},
+		{"{\"id\":1,\"ts\":100,\"name\":\"a\"}", "{\"name\":\"a\",\"ts\":200,\"id\":1}", nil, false},
+		{"{\"id\":1,\"ts\":100,\"name\":\"a\"}", "{\"name\":\"b\",\"ts\":200,\"id\":1}", []string{"/ts"}, false},
+		{"{\"id\":1,\"ts\":100}", "{\"id\":1}", []string{"/ts"}, true},
+		{"{\"data\":[{\"id\":\"x\",\"v\":1},{\"id\":\"y\",\"v\":2}]}", "{\"data\":[{\"id\":\"p\",\"v\":1},{\"id\":\"q\",\"v\":2}]}", []string{"/data/0/id", "/data/1/id"}, true},
+		{"{\"data\":[{\"id\":\"x\",\"v\":1},{\"id\":\"y\",\"v\":2}]}", "{\"data\":[{\"id\":\"p\",\"v\":1},{\"id\":\"q\",\"v\":2}]}", []string{"/data/0/id"}, false},
+		{"[1, 2, 3]", "[1, 5, 3]", []string{"/1"}, true},
+		{"[1, 2, 3]", "[1, 5]", []string{"/1"}, false},
+		{"1", "2", []string{""}, true},
+		{"{\"a\":1}", "{\"a\":1}", []string{"/missing/x", "bad"}, true},
+	}
+	for _, c := range valid {
+		a, b := NewLeptValue(), NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(a, c.a))
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(b, c.b))
+		origin := LeptStringify(a)
+		if LeptIsEqualIgnoring(a, b, c.ignore) != c.expect {
+			t.Errorf("LeptIsEqualIgnoring %v %v %v expect: %v", c.a, c.b, c.ignore, c.expect)
+		}
+		// the inputs are not changed
+		expectEQString(t, origin, LeptStringify(a))
+	}
+}
+
+func TestLeptRedact(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"user\":{\"name\":\"a\",\"password\":\"p\",\"tokens\":[\"t1\",\"t2\"]},\"db\":{\"dsn\":{\"host\":\"h\",\"secret\":\"s\"}}}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	mask := NewLeptValue()
+	LeptSetString(mask, "***")
+
+	ret := LeptRedact(v, []string{"/user/password", "/user/tokens/1", "/db/dsn", "/user/missing", "/user/tokens/9"}, mask)
+	expectEQString(t, "{\"user\":{\"name\":\"a\",\"password\":\"***\",\"tokens\":[\"t1\",\"***\"]},\"db\":{\"dsn\":\"***\"}}", LeptStringify(ret))
+	// v is not changed
+	expectEQString(t, input, LeptStringify(v))
+	// the replacement is copied
+	LeptSetString(mask, "changed")
+	expectEQString(t, "***", LeptGetString(LeptFindObjectValue(LeptFindObjectValue(ret, "user"), "password")))
+
+	LeptSetNull(mask)
+	expectEQString(t, "null", LeptStringify(LeptRedact(v, []string{""}, mask)))
+	expectEQString(t, input, LeptStringify(LeptRedact(v, nil, mask)))
+}
+
+func TestLeptSplice(t *testing.T) {
+	valid := []struct {
+		root    string
+		pointer string
+		subtree string
+		expect  string
+	}{
+		{"{\"a\":{\"b\":1}}", "/a/b", "{\"x\":[1,2]}", "{\"a\":{\"b\":{\"x\":[1,2]}}}"},
+		{"{\"a\":{}}", "/a/b/c", "{\"x\":true}", "{\"a\":{\"b\":{\"c\":{\"x\":true}}}}"},
+		{"{}", "/a~1b/m~0n", "1", "{\"a/b\":{\"m~n\":1}}"},
+		{"{\"a\":null}", "/a/b", "2", "{\"a\":{\"b\":2}}"},
+		{"{\"list\":[1,{\"k\":0}]}", "/list/1/k", "{\"y\":null}", "{\"list\":[1,{\"k\":{\"y\":null}}]}"},
+		{"{\"list\":[1]}", "/list/0", "\"s\"", "{\"list\":[\"s\"]}"},
+		{"{\"list\":[1]}", "/list/1", "2", "{\"list\":[1,2]}"},
+		{"{\"list\":[1]}", "/list/-/a", "3", "{\"list\":[1,{\"a\":3}]}"},
+		{"[1,2]", "", "{\"new\":[]}", "{\"new\":[]}"},
+	}
+	for _, c := range valid {
+		root, subtree := NewLeptValue(), NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(root, c.root))
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(subtree, c.subtree))
+		if err := LeptSplice(root, c.pointer, subtree); err != nil {
+			t.Errorf("LeptSplice %v %v expect no err: %v", c.root, c.pointer, err)
+		}
+		expectEQString(t, c.expect, LeptStringify(root))
+		// no aliasing
+		expectEQString(t, c.subtree, LeptStringify(subtree))
+	}
+
+	// graft a part of root into itself
+	root := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(root, "{\"a\":{\"x\":1}}"))
+	if err := LeptSplice(root, "/a/y", root); err != nil {
+		t.Errorf("LeptSplice expect no err: %v", err)
+	}
+	expectEQString(t, "{\"a\":{\"x\":1,\"y\":{\"a\":{\"x\":1}}}}", LeptStringify(root))
+	LeptSetNumber(LeptFindObjectValue(LeptFindObjectValue(root, "a"), "x"), 2)
+	expectEQString(t, "{\"a\":{\"x\":2,\"y\":{\"a\":{\"x\":1}}}}", LeptStringify(root))
+
+	invalid := []struct {
+		root    string
+		pointer string
+	}{
+		{"{\"a\":1}", "/a/b"},
+		{"{\"a\":\"s\"}", "/a/b/c"},
+		{"[1]", "/3"},
+		{"[1]", "/x"},
+		{"{}", "a"},
+		{"{}", "/~2"},
+		{"{\"a\":null,\"b\":[{\"c\":true}]}", "/b/0/c/d"},
+		{"[null,[]]", "/1/2/x"},
+	}
+	for _, c := range invalid {
+		root := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(root, c.root))
+		if err := LeptSplice(root, c.pointer, NewLeptValue()); err == nil {
+			t.Errorf("LeptSplice %v %v should have err", c.root, c.pointer)
+		}
+		// nothing is created on the way to the failure
+		expectEQString(t, c.root, LeptStringify(root))
+	}
+}
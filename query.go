@@ -0,0 +1,72 @@
+package goleptjson
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LeptToQueryString encode a flat object into the url query string sorted by key,
+// the scalars are written by LeptCoerceString, array of scalars become repeated keys,
+// nested object or array get an error
+func LeptToQueryString(v *LeptValue) (string, error) {
+	if v == nil || v.typ != LeptObject {
+		return "", fmt.Errorf("v LeptValue is not a object")
+	}
+	values := url.Values{}
+	for _, member := range v.o {
+		switch member.value.typ {
+		case LeptObject:
+			return "", fmt.Errorf("v LeptValue member %q is a nested object", member.key)
+		case LeptArray:
+			for i, e := range member.value.a {
+				if e.typ == LeptArray || e.typ == LeptObject {
+					return "", fmt.Errorf("v LeptValue member %q element %v is not a scalar: %v", member.key, i, e.typ)
+				}
+				values.Add(member.key, LeptCoerceString(e))
+			}
+		default:
+			values.Add(member.key, LeptCoerceString(member.value))
+		}
+	}
+	return values.Encode(), nil
+}
+
+// LeptFromQueryString decode the url query string into an object of string values in order of appearance,
+// the repeated keys become array
+func LeptFromQueryString(query string) (*LeptValue, error) {
+	v := NewLeptValue()
+	LeptSetObject(v)
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value := pair, ""
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			key, value = pair[:i], pair[i+1:]
+		}
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return nil, err
+		}
+		e := NewLeptValue()
+		LeptSetString(e, value)
+		if index := LeptFindObjectIndex(v, key); index != LeptKeyNotExist {
+			member := v.o[index]
+			if member.value.typ != LeptArray {
+				first := member.value
+				member.value = NewLeptValue()
+				member.value.typ = LeptArray
+				member.value.a = []*LeptValue{first}
+			}
+			member.value.a = append(member.value.a, e)
+			continue
+		}
+		v.o = append(v.o, &LeptMember{key: key, value: e})
+	}
+	return v, nil
+}
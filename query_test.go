@@ -0,0 +1,79 @@
+package goleptjson
+
+import (
+	"testing"
+)
+
+func TestLeptToQueryString(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"{}", ""},
+		{"{\"b\":2,\"a\":1}", "a=1&b=2"},
+		{"{\"s\":\"hello world\",\"t\":true,\"f\":false,\"n\":null,\"x\":1.5}", "f=false&n=&s=hello+world&t=true&x=1.5"},
+		{"{\"q\":\"a&b=c/d?e#f%\"}", "q=a%26b%3Dc%2Fd%3Fe%23f%25"},
+		{"{\"k e y\":\"\\u00e9\"}", "k+e+y=%C3%A9"},
+		{"{\"tag\":[\"a\",\"b\",1],\"id\":7}", "id=7&tag=a&tag=b&tag=1"},
+		{"{\"empty\":[]}", ""},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		s, err := LeptToQueryString(v)
+		if err != nil {
+			t.Errorf("LeptToQueryString %v expect no err: %v", c.input, err)
+		}
+		expectEQString(t, c.expect, s)
+	}
+	invalid := []string{"[]", "1", "{\"a\":{}}", "{\"a\":[1,[2]]}", "{\"a\":[{}]}"}
+	for _, input := range invalid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+		if _, err := LeptToQueryString(v); err == nil {
+			t.Errorf("LeptToQueryString %v should have err", input)
+		}
+	}
+}
+
+func TestLeptFromQueryString(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"", "{}"},
+		{"b=2&a=1", "{\"b\":\"2\",\"a\":\"1\"}"},
+		{"q=a%26b%3Dc&s=hello+world&p=100%25", "{\"q\":\"a&b=c\",\"s\":\"hello world\",\"p\":\"100%\"}"},
+		{"k+e+y=%C3%A9", "{\"k e y\":\"\\u00e9\"}"},
+		{"tag=a&id=7&tag=b&tag=", "{\"tag\":[\"a\",\"b\",\"\"],\"id\":\"7\"}"},
+		{"flag&x=&&y==", "{\"flag\":\"\",\"x\":\"\",\"y\":\"=\"}"},
+	}
+	for _, c := range valid {
+		v, err := LeptFromQueryString(c.input)
+		if err != nil {
+			t.Errorf("LeptFromQueryString %v expect no err: %v", c.input, err)
+			continue
+		}
+		expect := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(expect, c.expect))
+		if !LeptIsEqual(expect, v) {
+			t.Errorf("LeptFromQueryString %v expect: %v, actual: %v", c.input, c.expect, LeptStringify(v))
+		}
+	}
+	// keep the order of appearance
+	v, _ := LeptFromQueryString("z=1&a=2&m=3")
+	expectEQString(t, "{\"z\":\"1\",\"a\":\"2\",\"m\":\"3\"}", LeptStringify(v))
+	// the inverse of LeptToQueryString
+	s, err := LeptToQueryString(v)
+	if err != nil {
+		t.Errorf("LeptToQueryString expect no err: %v", err)
+	}
+	expectEQString(t, "a=2&m=3&z=1", s)
+
+	invalid := []string{"a=%zz", "%=1", "a=%4"}
+	for _, input := range invalid {
+		if _, err := LeptFromQueryString(input); err == nil {
+			t.Errorf("LeptFromQueryString %v should have err", input)
+		}
+	}
+}
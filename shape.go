@@ -0,0 +1,231 @@
+package goleptjson
+
+import (
+	"bytes"
+	"sort"
+)
+
+// leptShape the merged structure of some values, the scalar values are ignored
+type leptShape struct {
+	null    bool
+	boolean bool
+	number  bool
+	str     bool
+
+	array bool
+	items *leptShape // nil if all the arrays are empty
+
+	object  int // number of objects merged
+	keys    []string
+	fields  map[string]*leptShape
+	present map[string]int // number of objects having the key
+}
+
+// merge the structure of v into shape
+func (shape *leptShape) merge(v *LeptValue) {
+	switch v.typ {
+	case LeptNull:
+		shape.null = true
+	case LeptFalse, LeptTrue:
+		shape.boolean = true
+	case LeptNumber:
+		shape.number = true
+	case LeptString:
+		shape.str = true
+	case LeptArray:
+		shape.array = true
+		for _, e := range v.a {
+			if shape.items == nil {
+				shape.items = &leptShape{}
+			}
+			shape.items.merge(e)
+		}
+	case LeptObject:
+		if shape.fields == nil {
+			shape.fields = make(map[string]*leptShape)
+			shape.present = make(map[string]int)
+		}
+		shape.object++
+		seen := make(map[string]bool, len(v.o))
+		for _, member := range v.o {
+			field := shape.fields[member.key]
+			if field == nil {
+				field = &leptShape{}
+				shape.fields[member.key] = field
+				shape.keys = append(shape.keys, member.key)
+			}
+			field.merge(member.value)
+			if !seen[member.key] {
+				seen[member.key] = true
+				shape.present[member.key]++
+			}
+		}
+	}
+}
+
+func (shape *leptShape) write(buf *bytes.Buffer) {
+	union := false
+	kind := func(name string) {
+		if union {
+			buf.WriteByte('|')
+		}
+		union = true
+		buf.WriteString(name)
+	}
+	if shape.null {
+		kind("null")
+	}
+	if shape.boolean {
+		kind("boolean")
+	}
+	if shape.number {
+		kind("number")
+	}
+	if shape.str {
+		kind("string")
+	}
+	if shape.array {
+		kind("[")
+		if shape.items != nil {
+			shape.items.write(buf)
+		}
+		buf.WriteByte(']')
+	}
+	if shape.object > 0 {
+		kind("{")
+		keys := make([]string, len(shape.keys))
+		copy(keys, shape.keys)
+		sort.Strings(keys)
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if leptIsShapeKey(key) {
+				buf.WriteString(key)
+			} else {
+				buf.WriteString(leptStringifyString(key))
+			}
+			if shape.present[key] < shape.object {
+				buf.WriteByte('?')
+			}
+			buf.WriteByte(':')
+			shape.fields[key].write(buf)
+		}
+		buf.WriteByte('}')
+	}
+}
+
+// leptIsShapeKey the key is written without quotation mark
+func leptIsShapeKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		if !(isDigit(ch) || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_' || ch == '$' || ch == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// LeptShape return the compact signature of the structure of v, types and keys without the scalar values,
+// like {name:string,tags:[string]}, the elements of array are merged like [number|string] or [{a:number,b?:string}],
+// the key missing in some of the merged objects is marked by ?, the keys are sorted so the order of members does not matter
+func LeptShape(v *LeptValue) string {
+	if v == nil {
+		panic("LeptShape v is nil")
+	}
+	shape := &leptShape{}
+	shape.merge(v)
+	var buf bytes.Buffer
+	shape.write(&buf)
+	return buf.String()
+}
+
+// schema write the JSON-Schema-like description of shape into v
+func (shape *leptShape) schema(v *LeptValue) {
+	LeptSetObject(v)
+	var types []string
+	if shape.null {
+		types = append(types, "null")
+	}
+	if shape.boolean {
+		types = append(types, "boolean")
+	}
+	if shape.number {
+		types = append(types, "number")
+	}
+	if shape.str {
+		types = append(types, "string")
+	}
+	if shape.array {
+		types = append(types, "array")
+	}
+	if shape.object > 0 {
+		types = append(types, "object")
+	}
+	if len(types) == 0 {
+		return
+	}
+	typ := LeptSetObjectValue(v, "type")
+	if len(types) == 1 {
+		LeptSetString(typ, types[0])
+	} else {
+		leptSetStringArray(typ, types)
+	}
+	if shape.array && shape.items != nil {
+		shape.items.schema(LeptSetObjectValue(v, "items"))
+	}
+	if shape.object > 0 {
+		properties := LeptSetObjectValue(v, "properties")
+		LeptSetObject(properties)
+		required := make([]string, 0)
+		for _, key := range shape.keys {
+			shape.fields[key].schema(LeptSetObjectValue(properties, key))
+			if shape.present[key] == shape.object {
+				required = append(required, key)
+			}
+		}
+		leptSetStringArray(LeptSetObjectValue(v, "required"), required)
+	}
+}
+
+func leptSetStringArray(v *LeptValue, s []string) {
+	LeptFree(v)
+	v.typ = LeptArray
+	v.a = make([]*LeptValue, len(s))
+	for i := range s {
+		v.a[i] = NewLeptValue()
+		LeptSetString(v.a[i], s[i])
+	}
+}
+
+// LeptInferSchema return a minimal JSON-Schema-like object describing the common structure of samples,
+// with "type", "items" of array, "properties" and "required" of object,
+// the key absent in some samples is not required, no samples get {} accepting anything
+func LeptInferSchema(samples []*LeptValue) *LeptValue {
+	shape := &leptShape{}
+	for _, sample := range samples {
+		if sample == nil {
+			panic("LeptInferSchema sample is nil")
+		}
+		shape.merge(sample)
+	}
+	v := NewLeptValue()
+	shape.schema(v)
+	return v
+}
+
+// LeptSameShape check a and b have the same structure ignoring the scalar values, that is the same LeptShape,
+// true and false are both boolean, the elements of array are merged so [1, "a"], ["a", 1, 2] and ["b", 3] agree,
+// the objects have the same set of keys with the values of the same shape regardless of the order of members
+func LeptSameShape(a, b *LeptValue) bool {
+	if a == nil || b == nil {
+		panic("LeptSameShape a or b is nil")
+	}
+	if a == b {
+		return true
+	}
+	return LeptShape(a) == LeptShape(b)
+}
@@ -0,0 +1,114 @@
+package goleptjson
+
+import (
+	"testing"
+)
+
+func TestLeptShape(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect string
+	}{
+		{"null", "null"},
+		{"true", "boolean"},
+		{"1", "number"},
+		{"\"a\"", "string"},
+		{"[]", "[]"},
+		{"{}", "{}"},
+		{"{\"name\":\"a\",\"tags\":[\"x\",\"y\"]}", "{name:string,tags:[string]}"},
+		{"[1, \"a\", 2, null]", "[null|number|string]"},
+		{"[[1], [], [[true]]]", "[[number|[boolean]]]"},
+		{"[{\"a\":1}, {\"a\":2,\"b\":\"x\"}, {\"b\":null}]", "[{a?:number,b?:null|string}]"},
+		{"[{\"a\":1}, [2], 3]", "[number|[number]|{a:number}]"},
+		{"{\"user\":{\"id\":1,\"roles\":[{\"name\":\"r\",\"admin\":false}]},\"a b\":1,\"\":2}", "{\"\":number,\"a b\":number,user:{id:number,roles:[{admin:boolean,name:string}]}}"},
+		{"[{\"b\":1,\"a\":\"x\"}, {\"a\":\"y\",\"c\":true}]", "[{a:string,b?:number,c?:boolean}]"},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQString(t, c.expect, LeptShape(v))
+	}
+	// the same structure with different scalar values
+	a, b := NewLeptValue(), NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(a, "{\"id\":1,\"tags\":[\"x\"]}"))
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(b, "{\"id\":99,\"tags\":[\"y\",\"z\"]}"))
+	expectEQString(t, LeptShape(a), LeptShape(b))
+	// the order of members does not matter
+	b = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(b, "{\"tags\":[],\"id\":2}"))
+	expectEQString(t, "{id:number,tags:[]}", LeptShape(b))
+}
+
+func TestLeptInferSchema(t *testing.T) {
+	inputs := []string{
+		"{\"id\":1,\"name\":\"a\",\"tags\":[\"x\"],\"owner\":{\"id\":1}}",
+		"{\"id\":2,\"name\":\"b\",\"tags\":[],\"owner\":null}",
+		"{\"id\":3,\"tags\":[\"y\",\"z\"],\"extra\":true,\"owner\":{\"id\":2,\"email\":\"e\"}}",
+	}
+	samples := make([]*LeptValue, len(inputs))
+	for i, input := range inputs {
+		samples[i] = NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(samples[i], input))
+	}
+	expect := "{\"type\":\"object\",\"properties\":{" +
+		"\"id\":{\"type\":\"number\"}," +
+		"\"name\":{\"type\":\"string\"}," +
+		"\"tags\":{\"type\":\"array\",\"items\":{\"type\":\"string\"}}," +
+		"\"owner\":{\"type\":[\"null\",\"object\"],\"properties\":{\"id\":{\"type\":\"number\"},\"email\":{\"type\":\"string\"}},\"required\":[\"id\"]}," +
+		"\"extra\":{\"type\":\"boolean\"}" +
+		"},\"required\":[\"id\",\"tags\",\"owner\"]}"
+	expectEQString(t, expect, LeptStringify(LeptInferSchema(samples)))
+
+	expectEQString(t, "{}", LeptStringify(LeptInferSchema(nil)))
+	valid := []struct {
+		inputs []string
+		expect string
+	}{
+		{[]string{"[]"}, "{\"type\":\"array\"}"},
+		{[]string{"[]", "[1, 2]"}, "{\"type\":\"array\",\"items\":{\"type\":\"number\"}}"},
+		{[]string{"[1]", "\"a\"", "null"}, "{\"type\":[\"null\",\"string\",\"array\"],\"items\":{\"type\":\"number\"}}"},
+		{[]string{"{}", "{\"a\":1}"}, "{\"type\":\"object\",\"properties\":{\"a\":{\"type\":\"number\"}},\"required\":[]}"},
+	}
+	for _, c := range valid {
+		samples := make([]*LeptValue, len(c.inputs))
+		for i, input := range c.inputs {
+			samples[i] = NewLeptValue()
+			expectEQLeptEvent(t, LeptParseOK, LeptParse(samples[i], input))
+		}
+		expectEQString(t, c.expect, LeptStringify(LeptInferSchema(samples)))
+	}
+}
+
+func TestLeptSameShape(t *testing.T) {
+	valid := []struct {
+		a, b   string
+		expect bool
+	}{
+		{"null", "null", true},
+		{"true", "false", true},
+		{"1", "2.5", true},
+		{"\"a\"", "\"b\"", true},
+		{"[1, \"a\"]", "[2, \"b\"]", true},
+		{"{\"id\":1,\"name\":\"a\"}", "{\"name\":\"b\",\"id\":2}", true},
+		{"{\"a\":{\"b\":[true]}}", "{\"a\":{\"b\":[false]}}", true},
+		{"null", "0", false},
+		{"1", "\"1\"", false},
+		{"[1, \"a\"]", "[\"a\", 1, 2]", true},
+		{"[1]", "[1, 2]", true},
+		{"[{\"a\":1}, {\"a\":2,\"b\":true}]", "[{\"b\":false,\"a\":0}, {\"a\":3}]", true},
+		{"[1]", "[1, \"a\"]", false},
+		{"[]", "[1]", false},
+		{"[{\"a\":1}, {\"a\":2}]", "[{\"a\":1}, {\"b\":2}]", false},
+		{"[]", "{}", false},
+		{"{\"a\":1}", "{\"a\":1,\"b\":1}", false},
+		{"{\"a\":1}", "{\"b\":1}", false},
+		{"{\"a\":{\"b\":1}}", "{\"a\":{\"b\":null}}", false},
+	}
+	for _, c := range valid {
+		a, b := NewLeptValue(), NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(a, c.a))
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(b, c.b))
+		expectEQBool(t, c.expect, LeptSameShape(a, b))
+		expectEQBool(t, c.expect, LeptSameShape(b, a))
+	}
+}
@@ -0,0 +1,160 @@
+package goleptjson
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LeptStringifyOptions hold the options of LeptStringifyWithOptions, nil means the default options
+type LeptStringifyOptions struct {
+	// KeepDecimalPoint keep a decimal point for the whole number like 2.0 to signal it is a float,
+	// false write the number in the shortest form which round trips like 1.5 and 2, the default
+	KeepDecimalPoint bool
+	// SortKeys write the object members in key order by KeySortMode for canonical output,
+	// false keep the insertion order
+	SortKeys bool
+	// KeySortMode the order of SortKeys
+	KeySortMode LeptKeySortMode
+	// Indent put each element of array and member of object on its own line,
+	// indented by Indent per level of nesting, the empty array and object stay [] and {},
+	// empty means compact
+	Indent string
+	// AllowNonFinite write NaN and infinity as NaN, Infinity and -Infinity like AllowNonFinite of LeptParseOptions,
+	// false write them as null so that the output stays valid json
+	AllowNonFinite bool
+}
+
+// LeptKeySortMode the order of object keys
+type LeptKeySortMode int
+
+const (
+	// LeptKeySortLexicographic compare the keys by utf8 bytes, "10" < "2", the default
+	LeptKeySortLexicographic LeptKeySortMode = iota
+	// LeptKeySortNumeric compare the digit runs of keys by number, "2" < "10" and "a2" < "a10"
+	LeptKeySortNumeric
+)
+
+var defaultLeptStringifyOptions = LeptStringifyOptions{}
+
+// NewLeptStringifyOptions return the default LeptStringifyOptions
+func NewLeptStringifyOptions() *LeptStringifyOptions {
+	opts := defaultLeptStringifyOptions
+	return &opts
+}
+
+// LeptStringifyWithOptions use to stringify value compact with the options
+func LeptStringifyWithOptions(v *LeptValue, opts *LeptStringifyOptions) string {
+	if v == nil {
+		panic("LeptStringifyWithOptions v is nil")
+	}
+	if opts == nil {
+		opts = &defaultLeptStringifyOptions
+	}
+	return leptStringifyValue(v, opts)
+}
+
+// LeptStringifyIndent use to stringify value human readable, indented by indent per level of nesting
+func LeptStringifyIndent(v *LeptValue, indent string) string {
+	if v == nil {
+		panic("LeptStringifyIndent v is nil")
+	}
+	opts := NewLeptStringifyOptions()
+	opts.Indent = indent
+	return leptStringifyValue(v, opts)
+}
+
+// LeptStringifyCanonical use to stringify value compact with the object keys sorted by utf8 bytes at every level
+// and the numbers in the shortest form, the same value always gives the same bytes, useful to hash
+func LeptStringifyCanonical(v *LeptValue) string {
+	if v == nil {
+		panic("LeptStringifyCanonical v is nil")
+	}
+	opts := NewLeptStringifyOptions()
+	opts.SortKeys = true
+	opts.KeySortMode = LeptKeySortLexicographic
+	return leptStringifyValue(v, opts)
+}
+
+// indent write the element s on its own line of a container, s never has a raw newline except its own layout
+func (opts *LeptStringifyOptions) indent(buf *bytes.Buffer, s string) {
+	buf.WriteByte('\n')
+	buf.WriteString(opts.Indent)
+	buf.WriteString(strings.Replace(s, "\n", "\n"+opts.Indent, -1))
+}
+
+func (opts *LeptStringifyOptions) formatNumber(n float64) string {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		if opts.AllowNonFinite {
+			for _, token := range leptNonFiniteTokens {
+				if n == token.n || math.IsNaN(n) && math.IsNaN(token.n) {
+					return token.literal
+				}
+			}
+		}
+		return "null"
+	}
+	s := strconv.FormatFloat(n, 'g', -1, 64)
+	if !opts.KeepDecimalPoint || strings.IndexByte(s, '.') >= 0 {
+		return s
+	}
+	// 2 -> 2.0, 1e+21 -> 1.0e+21
+	if i := strings.IndexByte(s, 'e'); i >= 0 {
+		return s[:i] + ".0" + s[i:]
+	}
+	return s + ".0"
+}
+
+// sortMembers return the members sorted by KeySortMode, the members are not changed
+func (opts *LeptStringifyOptions) sortMembers(members []*LeptMember) []*LeptMember {
+	sorted := make([]*LeptMember, len(members))
+	copy(sorted, members)
+	less := func(i, j int) bool {
+		return sorted[i].key < sorted[j].key
+	}
+	if opts.KeySortMode == LeptKeySortNumeric {
+		less = func(i, j int) bool {
+			return leptNumericKeyLess(sorted[i].key, sorted[j].key)
+		}
+	}
+	sort.SliceStable(sorted, less)
+	return sorted
+}
+
+// leptNumericKeyLess compare the runs of digits by number and the others by bytes,
+// the numbers equal with leading zeros like "01" "1" are ordered by bytes
+func leptNumericKeyLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				return a[i] < b[j]
+			}
+			i++
+			j++
+			continue
+		}
+		ei, ej := i, j
+		for ei < len(a) && isDigit(a[ei]) {
+			ei++
+		}
+		for ej < len(b) && isDigit(b[ej]) {
+			ej++
+		}
+		na := strings.TrimLeft(a[i:ei], "0")
+		nb := strings.TrimLeft(b[j:ej], "0")
+		if len(na) != len(nb) {
+			return len(na) < len(nb)
+		}
+		if na != nb {
+			return na < nb
+		}
+		i, j = ei, ej
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
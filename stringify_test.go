@@ -0,0 +1,122 @@
+package goleptjson
+
+import (
+	"testing"
+)
+
+func TestLeptStringifyKeepDecimalPoint(t *testing.T) {
+	expectEQBool(t, false, NewLeptStringifyOptions().KeepDecimalPoint)
+	valid := []struct {
+		input string
+		strip string
+		keep  string
+	}{
+		{"1.50", "1.5", "1.5"},
+		{"2.0", "2", "2.0"},
+		{"0", "0", "0.0"},
+		{"-0.0", "-0", "-0.0"},
+		{"100", "100", "100.0"},
+		{"0.1", "0.1", "0.1"},
+		{"1e21", "1e+21", "1.0e+21"},
+		{"1.25e-7", "1.25e-07", "1.25e-07"},
+		{"1.7976931348623157e308", "1.7976931348623157e+308", "1.7976931348623157e+308"},
+		{"[1.0, {\"a\":2.50}]", "[1,{\"a\":2.5}]", "[1.0,{\"a\":2.5}]"},
+	}
+	keep := NewLeptStringifyOptions()
+	keep.KeepDecimalPoint = true
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		expectEQString(t, c.strip, LeptStringifyWithOptions(v, nil))
+		expectEQString(t, c.strip, LeptStringifyWithOptions(v, NewLeptStringifyOptions()))
+		// the zero value options are the default
+		expectEQString(t, c.strip, LeptStringifyWithOptions(v, &LeptStringifyOptions{}))
+		expectEQString(t, c.strip, LeptStringifyWithOptions(v, &LeptStringifyOptions{SortKeys: true}))
+		expectEQString(t, c.keep, LeptStringifyWithOptions(v, keep))
+		// both round trip
+		for _, opts := range []*LeptStringifyOptions{nil, keep} {
+			rv := NewLeptValue()
+			expectEQLeptEvent(t, LeptParseOK, LeptParse(rv, LeptStringifyWithOptions(v, opts)))
+			expectEQBool(t, true, LeptIsEqual(v, rv))
+		}
+	}
+}
+
+func TestLeptStringifyKeySortMode(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"10\":1,\"2\":2,\"1\":3,\"b\":4,\"a10\":5,\"a2\":6,\"01\":7,\"\":8,\"x\":{\"20\":1,\"3\":2}}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	opts := NewLeptStringifyOptions()
+	// insertion order by default
+	expectEQString(t, "{\"10\":1,\"2\":2,\"1\":3,\"b\":4,\"a10\":5,\"a2\":6,\"01\":7,\"\":8,\"x\":{\"20\":1,\"3\":2}}", LeptStringifyWithOptions(v, opts))
+	opts.SortKeys = true
+	expectEQInt(t, int(LeptKeySortLexicographic), int(opts.KeySortMode))
+	expectEQString(t, "{\"\":8,\"01\":7,\"1\":3,\"10\":1,\"2\":2,\"a10\":5,\"a2\":6,\"b\":4,\"x\":{\"20\":1,\"3\":2}}", LeptStringifyWithOptions(v, opts))
+	opts.KeySortMode = LeptKeySortNumeric
+	expectEQString(t, "{\"\":8,\"01\":7,\"1\":3,\"2\":2,\"10\":1,\"a2\":6,\"a10\":5,\"b\":4,\"x\":{\"3\":2,\"20\":1}}", LeptStringifyWithOptions(v, opts))
+	// the members are not changed
+	expectEQString(t, "10", LeptGetObjectKey(v, 0))
+
+	less := []struct {
+		a string
+		b string
+	}{
+		{"2", "10"},
+		{"9", "10"},
+		{"a", "b"},
+		{"a2", "a10"},
+		{"a2b", "a2c"},
+		{"1", "a"},
+		{"a", "a1"},
+		{"01", "1"},
+		{"1", "02"},
+		{"99999999999999999999", "100000000000000000000"},
+	}
+	for _, c := range less {
+		expectEQBool(t, true, leptNumericKeyLess(c.a, c.b))
+		expectEQBool(t, false, leptNumericKeyLess(c.b, c.a))
+	}
+	expectEQBool(t, false, leptNumericKeyLess("a1", "a1"))
+}
+
+func TestLeptStringifyIndent(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"n\":null,\"a\":[1,\"x\",[],{}],\"o\":{\"t\":true,\"s\":\"a\\nb\",\"e\":{}}}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	golden := `{
+  "n": null,
+  "a": [
+    1,
+    "x",
+    [],
+    {}
+  ],
+  "o": {
+    "t": true,
+    "s": "a\nb",
+    "e": {}
+  }
+}`
+	expectEQString(t, golden, LeptStringifyIndent(v, "  "))
+	// empty indent is compact
+	expectEQString(t, input, LeptStringifyIndent(v, ""))
+	// the output parses back to the same value
+	w := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(w, LeptStringifyIndent(v, "\t")))
+	expectEQBool(t, true, LeptIsEqual(v, w))
+	expectEQString(t, "[]", LeptStringifyIndent(&LeptValue{typ: LeptArray}, "  "))
+	expectPanic(t, "LeptStringifyIndent", func() { LeptStringifyIndent(nil, "  ") })
+}
+
+func TestLeptStringifyCanonical(t *testing.T) {
+	a := NewLeptValue()
+	b := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(a, "{\"b\":[1.50,{\"y\":1,\"x\":2}],\"a\":\"\u00e9\",\"\u00e8\":1E2,\"A\":null}"))
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(b, "{ \"A\" : null, \"\u00e8\" : 100, \"a\" : \"\\u00e9\", \"b\" : [ 1.5, { \"x\" : 2, \"y\" : 1 } ] }"))
+	expect := "{\"A\":null,\"a\":\"\u00e9\",\"b\":[1.5,{\"x\":2,\"y\":1}],\"\u00e8\":100}"
+	expectEQString(t, expect, LeptStringifyCanonical(a))
+	expectEQString(t, expect, LeptStringifyCanonical(b))
+	// the members are not changed
+	expectEQString(t, "b", LeptGetObjectKey(a, 0))
+	expectPanic(t, "LeptStringifyCanonical", func() { LeptStringifyCanonical(nil) })
+}
@@ -0,0 +1,92 @@
+package goleptjson
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// LeptArrayOfObjectsToRows treat an array of objects as a table,
+// extract the named columns of each object into a row, nil for missing key
+func LeptArrayOfObjectsToRows(v *LeptValue, columns []string) ([][]interface{}, error) {
+	if v == nil || v.typ != LeptArray {
+		return nil, fmt.Errorf("v LeptValue is not a array")
+	}
+	rows := make([][]interface{}, 0, len(v.a))
+	for i, e := range v.a {
+		if e.typ != LeptObject {
+			return nil, fmt.Errorf("v LeptValue element %v is not a object: %v", i, e.typ)
+		}
+		row := make([]interface{}, len(columns))
+		for j, column := range columns {
+			if value := LeptFindObjectValue(e, column); value != nil {
+				row[j] = ToInterface(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// LeptUnionKeys return the sorted union of the keys of an array of objects, like the header of LeptToCSV
+func LeptUnionKeys(arr *LeptValue) ([]string, error) {
+	if arr == nil || arr.typ != LeptArray {
+		return nil, fmt.Errorf("arr LeptValue is not a array")
+	}
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	for i, e := range arr.a {
+		if e.typ != LeptObject {
+			return nil, fmt.Errorf("arr LeptValue element %v is not a object: %v", i, e.typ)
+		}
+		for _, member := range e.o {
+			if !seen[member.key] {
+				seen[member.key] = true
+				keys = append(keys, member.key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// LeptToCSV write the columns as header and the rows of an array of objects to w
+func LeptToCSV(v *LeptValue, columns []string, w io.Writer) error {
+	if v == nil || v.typ != LeptArray {
+		return fmt.Errorf("v LeptValue is not a array")
+	}
+	// check every element before writing, so a bad element leaves w untouched
+	for i, e := range v.a {
+		if e.typ != LeptObject {
+			return fmt.Errorf("v LeptValue element %v is not a object: %v", i, e.typ)
+		}
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, e := range v.a {
+		for j, column := range columns {
+			record[j] = csvField(LeptFindObjectValue(e, column))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvField missing key and null write as empty field, string as is, the others as compact json
+func csvField(field *LeptValue) string {
+	switch {
+	case field == nil || field.typ == LeptNull:
+		return ""
+	case field.typ == LeptString:
+		return field.s
+	default:
+		return LeptStringify(field)
+	}
+}
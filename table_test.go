@@ -0,0 +1,114 @@
+package goleptjson
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLeptArrayOfObjectsToRows(t *testing.T) {
+	v := NewLeptValue()
+	input := "[ {\"id\":1,\"name\":\"a\"}, {\"name\":\"b\",\"tag\":true}, {}, {\"id\":3,\"name\":null} ]"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	rows, err := LeptArrayOfObjectsToRows(v, []string{"id", "name", "tag"})
+	if err != nil {
+		t.Errorf("LeptArrayOfObjectsToRows expect no err: %v", err)
+	}
+	expect := [][]interface{}{
+		{1.0, "a", nil},
+		{nil, "b", true},
+		{nil, nil, nil},
+		{3.0, nil, nil},
+	}
+	if !reflect.DeepEqual(expect, rows) {
+		t.Errorf("LeptArrayOfObjectsToRows expect: %v, actual: %v", expect, rows)
+	}
+
+	invalid := []struct {
+		input string
+	}{
+		{"{}"},
+		{"null"},
+		{"[1]"},
+		{"[{}, []]"},
+	}
+	for _, c := range invalid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		if _, err := LeptArrayOfObjectsToRows(v, []string{"id"}); err == nil {
+			t.Errorf("LeptArrayOfObjectsToRows %v should have err", c.input)
+		}
+	}
+}
+
+func TestLeptToCSV(t *testing.T) {
+	v := NewLeptValue()
+	input := "[ {\"id\":1,\"name\":\"a, b\",\"tags\":[\"x\",\"y\"]}, {\"id\":2.5,\"name\":\"say \\\"hi\\\"\",\"ok\":false}, {\"ok\":true,\"name\":null} ]"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	var buf bytes.Buffer
+	if err := LeptToCSV(v, []string{"id", "name", "ok", "tags"}, &buf); err != nil {
+		t.Errorf("LeptToCSV expect no err: %v", err)
+	}
+	expect := "id,name,ok,tags\n" +
+		"1,\"a, b\",,\"[\"\"x\"\",\"\"y\"\"]\"\n" +
+		"2.5,\"say \"\"hi\"\"\",false,\n" +
+		",,true,\n"
+	expectEQString(t, expect, buf.String())
+
+	// the object keeps the order of members
+	buf.Reset()
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[{\"meta\":{\"b\":1,\"a\":[]},\"n\":1e21}]"))
+	if err := LeptToCSV(v, []string{"meta", "n"}, &buf); err != nil {
+		t.Errorf("LeptToCSV expect no err: %v", err)
+	}
+	expectEQString(t, "meta,n\n\"{\"\"b\"\":1,\"\"a\"\":[]}\",1e+21\n", buf.String())
+
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{}"))
+	if err := LeptToCSV(v, []string{"id"}, &buf); err == nil {
+		t.Errorf("LeptToCSV should have err for object")
+	}
+
+	// nothing is written when an element is not a object, even past the csv buffer
+	buf.Reset()
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "[{\"id\":\""+strings.Repeat("x", 8192)+"\"}, 2]"))
+	if err := LeptToCSV(v, []string{"id"}, &buf); err == nil {
+		t.Errorf("LeptToCSV should have err for element 1")
+	}
+	expectEQString(t, "", buf.String())
+}
+
+func TestLeptUnionKeys(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect []string
+	}{
+		{"[]", []string{}},
+		{"[{}, {}]", []string{}},
+		{"[{\"b\":1,\"a\":2}]", []string{"a", "b"}},
+		{"[{\"id\":1,\"name\":\"a\"}, {\"tag\":true}, {\"x\":1,\"y\":2}]", []string{"id", "name", "tag", "x", "y"}},
+		{"[{\"id\":1,\"name\":\"a\"}, {\"name\":\"b\",\"tag\":true}, {\"id\":3,\"Z\":0}]", []string{"Z", "id", "name", "tag"}},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, c.input))
+		keys, err := LeptUnionKeys(v)
+		if err != nil {
+			t.Errorf("LeptUnionKeys %v expect no err: %v", c.input, err)
+		}
+		if !reflect.DeepEqual(c.expect, keys) {
+			t.Errorf("LeptUnionKeys %v expect: %v, actual: %v", c.input, c.expect, keys)
+		}
+	}
+	invalid := []string{"{}", "null", "[1]", "[{}, []]"}
+	for _, input := range invalid {
+		v := NewLeptValue()
+		expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+		if _, err := LeptUnionKeys(v); err == nil {
+			t.Errorf("LeptUnionKeys %v should have err", input)
+		}
+	}
+}
@@ -0,0 +1,608 @@
+package goleptjson
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// the states of leptValidator
+const (
+	leptValidateEndTop          = iota // between the top level values
+	leptValidateBeginValue             // expect a value
+	leptValidateArrayOpen              // after [
+	leptValidateArrayElement           // after an element of array
+	leptValidateObjectOpen             // after {
+	leptValidateObjectKey              // expect a key after ,
+	leptValidateObjectColon            // after a key
+	leptValidateObjectMember           // after a member of object
+	leptValidateString                 // in string
+	leptValidateEscape                 // after \ in string
+	leptValidateHex                    // in \uXXXX
+	leptValidateSurrogateEscape        // expect \ of the low surrogate
+	leptValidateSurrogateU             // expect u of the low surrogate
+	leptValidateSurrogateHex           // in the \uXXXX of low surrogate
+	leptValidateNumber                 // in number
+	leptValidateLiteral                // in null true false
+	leptValidateEndTopScalar           // after a top level number or literal, expect whitespace
+)
+
+// leptValidator validate the whitespace separated json values byte by byte,
+// it reports the same events as LeptParse with the default options
+type leptValidator struct {
+	state   int
+	stack   []byte // [ or { of the open containers
+	key     bool   // the string is an object key
+	hex     int    // hex digits read of \uXXXX
+	u       rune
+	number  []byte
+	literal string
+	offset  int // bytes validated
+
+	maxNumber int // limit the bytes of number, 0 means unlimited
+}
+
+// step validate the next byte, LeptParseOK means it is fine so far
+func (d *leptValidator) step(ch byte) LeptEvent {
+	for {
+		switch d.state {
+		case leptValidateEndTop:
+			if !leptIsWhitespace(ch) {
+				d.state = leptValidateBeginValue
+				continue
+			}
+		case leptValidateEndTopScalar:
+			// 1true and nulltrue are not two values
+			if !leptIsWhitespace(ch) {
+				return LeptParseRootNotSingular
+			}
+			d.state = leptValidateEndTop
+		case leptValidateBeginValue:
+			if leptIsWhitespace(ch) {
+				break
+			}
+			switch ch {
+			case '[':
+				d.stack = append(d.stack, ch)
+				d.state = leptValidateArrayOpen
+			case '{':
+				d.stack = append(d.stack, ch)
+				d.state = leptValidateObjectOpen
+			case '"':
+				d.key = false
+				d.state = leptValidateString
+			case 'n':
+				d.beginLiteral("null")
+			case 't':
+				d.beginLiteral("true")
+			case 'f':
+				d.beginLiteral("false")
+			default:
+				if ch != '-' && !isDigit(ch) {
+					return LeptParseInvalidValue
+				}
+				d.number = append(d.number[:0], ch)
+				d.state = leptValidateNumber
+			}
+		case leptValidateArrayOpen:
+			if leptIsWhitespace(ch) {
+				break
+			}
+			if ch == ']' {
+				d.endContainer()
+				break
+			}
+			d.state = leptValidateBeginValue
+			continue
+		case leptValidateArrayElement:
+			if leptIsWhitespace(ch) {
+				break
+			}
+			switch ch {
+			case ',':
+				d.state = leptValidateBeginValue
+			case ']':
+				d.endContainer()
+			default:
+				return LeptParseMissCommaOrSouareBracket
+			}
+		case leptValidateObjectOpen:
+			if leptIsWhitespace(ch) {
+				break
+			}
+			if ch == '}' {
+				d.endContainer()
+				break
+			}
+			d.state = leptValidateObjectKey
+			continue
+		case leptValidateObjectKey:
+			if leptIsWhitespace(ch) {
+				break
+			}
+			if ch != '"' {
+				return LeptParseMissKey
+			}
+			d.key = true
+			d.state = leptValidateString
+		case leptValidateObjectColon:
+			if leptIsWhitespace(ch) {
+				break
+			}
+			if ch != ':' {
+				return LeptParseMissColon
+			}
+			d.state = leptValidateBeginValue
+		case leptValidateObjectMember:
+			if leptIsWhitespace(ch) {
+				break
+			}
+			switch ch {
+			case ',':
+				d.state = leptValidateObjectKey
+			case '}':
+				d.endContainer()
+			default:
+				return LeptParseMissCommaOrCurlyBracket
+			}
+		case leptValidateString:
+			switch {
+			case ch == '"':
+				if d.key {
+					d.state = leptValidateObjectColon
+				} else {
+					d.endValue()
+				}
+			case ch == '\\':
+				d.state = leptValidateEscape
+			case ch < 0x20:
+				return LeptParseInvalidStringChar
+			}
+		case leptValidateEscape:
+			switch ch {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				d.state = leptValidateString
+			case 'u':
+				d.hex, d.u = 0, 0
+				d.state = leptValidateHex
+			default:
+				return LeptParseInvalidStringEscape
+			}
+		case leptValidateHex, leptValidateSurrogateHex:
+			h := leptHexValue(ch)
+			if h < 0 {
+				if d.state == leptValidateSurrogateHex {
+					return LeptParseInvalidUnicodeSurrogate
+				}
+				return LeptParseInvalidUnicodeHex
+			}
+			d.u = d.u<<4 | h
+			if d.hex++; d.hex < 4 {
+				break
+			}
+			if d.state == leptValidateSurrogateHex {
+				if d.u < 0xDC00 || d.u > 0xDFFF {
+					return LeptParseInvalidUnicodeSurrogate
+				}
+				d.state = leptValidateString
+			} else if d.u >= 0xD800 && d.u <= 0xDBFF {
+				d.state = leptValidateSurrogateEscape
+			} else if d.u >= 0xDC00 && d.u <= 0xDFFF {
+				return LeptParseInvalidUnicodeSurrogate
+			} else {
+				d.state = leptValidateString
+			}
+		case leptValidateSurrogateEscape:
+			if ch != '\\' {
+				return LeptParseInvalidUnicodeSurrogate
+			}
+			d.state = leptValidateSurrogateU
+		case leptValidateSurrogateU:
+			if ch != 'u' {
+				return LeptParseInvalidUnicodeSurrogate
+			}
+			d.hex, d.u = 0, 0
+			d.state = leptValidateSurrogateHex
+		case leptValidateNumber:
+			if isDigit(ch) || ch == '-' || ch == '+' || ch == '.' || ch == 'e' || ch == 'E' {
+				if d.maxNumber > 0 && len(d.number) >= d.maxNumber {
+					return LeptParseNumberTooBig
+				}
+				d.number = append(d.number, ch)
+				break
+			}
+			if ch == 'x' && (string(d.number) == "0" || string(d.number) == "-0") {
+				// 0x0 is invalid like leptScanNumber
+				return LeptParseInvalidValue
+			}
+			if event := d.endNumber(); event != LeptParseOK {
+				return event
+			}
+			continue
+		case leptValidateLiteral:
+			if ch != d.literal[0] {
+				return LeptParseInvalidValue
+			}
+			if d.literal = d.literal[1:]; len(d.literal) == 0 {
+				d.endScalar()
+			}
+		}
+		d.offset++
+		return LeptParseOK
+	}
+}
+
+// eof check the end of stream, the stream must not stop inside a value
+func (d *leptValidator) eof() LeptEvent {
+	switch d.state {
+	case leptValidateNumber:
+		if event := d.endNumber(); event != LeptParseOK {
+			return event
+		}
+		return d.eof()
+	case leptValidateEndTop, leptValidateEndTopScalar:
+		return LeptParseOK
+	case leptValidateBeginValue:
+		return LeptParseExpectValue
+	case leptValidateArrayOpen, leptValidateArrayElement:
+		return LeptParseMissCommaOrSouareBracket
+	case leptValidateObjectOpen, leptValidateObjectMember:
+		return LeptParseMissCommaOrCurlyBracket
+	case leptValidateObjectKey:
+		return LeptParseMissKey
+	case leptValidateObjectColon:
+		return LeptParseMissColon
+	case leptValidateString:
+		return LeptParseMissQuotationMark
+	case leptValidateEscape:
+		return LeptParseInvalidStringEscape
+	case leptValidateHex:
+		return LeptParseInvalidUnicodeHex
+	case leptValidateSurrogateEscape, leptValidateSurrogateU, leptValidateSurrogateHex:
+		return LeptParseInvalidUnicodeSurrogate
+	default:
+		return LeptParseInvalidValue
+	}
+}
+
+func (d *leptValidator) beginLiteral(literal string) {
+	d.literal = literal[1:]
+	d.state = leptValidateLiteral
+}
+
+// endNumber check the buffered number by LeptParseNumber, so that the range and MaxExponent are the same
+func (d *leptValidator) endNumber() LeptEvent {
+	c := NewLeptContext(string(d.number))
+	if event := LeptParseNumber(c, NewLeptValue()); event != LeptParseOK {
+		return event
+	}
+	if !c.eof() {
+		return LeptParseInvalidValue
+	}
+	d.endScalar()
+	return LeptParseOK
+}
+
+func (d *leptValidator) endContainer() {
+	d.stack = d.stack[:len(d.stack)-1]
+	d.endValue()
+}
+
+// endScalar go to the state after a number or literal, which needs a delimiter at the top level
+func (d *leptValidator) endScalar() {
+	d.endValue()
+	if d.state == leptValidateEndTop {
+		d.state = leptValidateEndTopScalar
+	}
+}
+
+// endValue go to the state after a value by the open container
+func (d *leptValidator) endValue() {
+	if len(d.stack) == 0 {
+		d.state = leptValidateEndTop
+	} else if d.stack[len(d.stack)-1] == '[' {
+		d.state = leptValidateArrayElement
+	} else {
+		d.state = leptValidateObjectMember
+	}
+}
+
+func leptIsWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+func leptHexValue(ch byte) rune {
+	switch {
+	case isDigit(ch):
+		return rune(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		return rune(ch-'a') + 10
+	case ch >= 'A' && ch <= 'F':
+		return rune(ch-'A') + 10
+	}
+	return -1
+}
+
+// LeptValidatingWriter validate the bytes written to it as a stream of whitespace separated json values
+// and pass them through to the underlying writer, without buffering the values except a number,
+// a top level number or literal must be followed by whitespace or the end
+type LeptValidatingWriter struct {
+	// MaxNumberBytes limit the bytes of a number, the longer one is LeptParseNumberTooBig, 0 means unlimited
+	MaxNumberBytes int
+
+	w   io.Writer
+	v   leptValidator
+	err error
+}
+
+// LeptDefaultMaxNumberBytes the default MaxNumberBytes of LeptValidatingWriter
+const LeptDefaultMaxNumberBytes = 4096
+
+// NewLeptValidatingWriter return a LeptValidatingWriter write to w
+func NewLeptValidatingWriter(w io.Writer) *LeptValidatingWriter {
+	return &LeptValidatingWriter{w: w, MaxNumberBytes: LeptDefaultMaxNumberBytes}
+}
+
+// Write validate p and write it to the underlying writer, on the first invalid byte
+// only the bytes before it are written and *LeptError with the stream offset is returned,
+// the writer can not go on after an error
+func (w *LeptValidatingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.v.maxNumber = w.MaxNumberBytes
+	for i, ch := range p {
+		if event := w.v.step(ch); event != LeptParseOK {
+			w.err = &LeptError{Event: event, Offset: w.v.offset}
+			n, err := w.w.Write(p[:i])
+			if err != nil {
+				return n, err
+			}
+			return n, w.err
+		}
+	}
+	return w.w.Write(p)
+}
+
+// Close check the stream does not stop inside a value, it does not close the underlying writer
+func (w *LeptValidatingWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if event := w.v.eof(); event != LeptParseOK {
+		w.err = &LeptError{Event: event, Offset: w.v.offset}
+	}
+	return w.err
+}
+
+// LeptValidate check json is a single well formed value like LeptParse with the default options
+// and return the same event, but nothing is built, no string, array or object is allocated
+func LeptValidate(json string) LeptEvent {
+	c := LeptContext{json: json, opts: &defaultLeptParseOptions}
+	return leptValidate(&c, nil)
+}
+
+// leptValidate validate the whole input of c and report the values to h if it is not nil,
+// c stops at the same offset as leptParse on failure
+func leptValidate(c *LeptContext, h LeptEventHandler) LeptEvent {
+	LeptParseWhitespace(c)
+	if ret := leptSkipValue(c, h); ret != LeptParseOK {
+		return ret
+	}
+	LeptParseWhitespace(c)
+	if !c.eof() {
+		return LeptParseRootNotSingular
+	}
+	return LeptParseOK
+}
+
+// leptSkipValue validate a value and move over it like LeptParseValue
+func leptSkipValue(c *LeptContext, h LeptEventHandler) LeptEvent {
+	switch c.peek() {
+	case 'n', 't', 'f':
+		literal := "null"
+		if c.peek() == 't' {
+			literal = "true"
+		} else if c.peek() == 'f' {
+			literal = "false"
+		}
+		if !strings.HasPrefix(c.rest(), literal) {
+			return LeptParseInvalidValue
+		}
+		c.pos += len(literal)
+		if h != nil && literal == "null" {
+			h.OnNull()
+		} else if h != nil {
+			h.OnBool(literal == "true")
+		}
+		return LeptParseOK
+	case '"':
+		if h == nil {
+			return leptSkipString(c)
+		}
+		s, ret := LeptParseStringRaw(c)
+		if ret == LeptParseOK {
+			h.OnString(s)
+		}
+		return ret
+	case '[', '{':
+		if c.opts.MaxDepth > 0 && c.depth >= c.opts.MaxDepth {
+			return LeptParseMaxDepth
+		}
+		c.depth++
+		var event LeptEvent
+		if c.peek() == '[' {
+			event = leptSkipArray(c, h)
+		} else {
+			event = leptSkipObject(c, h)
+		}
+		c.depth--
+		return event
+	default:
+		if c.eof() {
+			return LeptParseExpectValue
+		}
+		return leptSkipNumber(c, h)
+	}
+}
+
+// leptSkipNumber validate a number like LeptParseNumber, the range is checked by strconv.ParseFloat
+func leptSkipNumber(c *LeptContext, h LeptEventHandler) LeptEvent {
+	end, ok := leptScanNumber(c.rest())
+	if !ok {
+		return LeptParseInvalidValue
+	}
+	literal := c.rest()[:end]
+	if c.opts.MaxExponent > 0 && leptExponentExceeds(literal, c.opts.MaxExponent) {
+		return LeptParseExponentTooLarge
+	}
+	n, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+			return LeptParseNumberTooBig
+		}
+		return LeptParseInvalidValue
+	}
+	c.pos += end
+	if h != nil {
+		h.OnNumber(n)
+	}
+	return LeptParseOK
+}
+
+// leptSkipString validate a string like LeptParseStringRaw, c stays after the opening quote on failure
+func leptSkipString(c *LeptContext) LeptEvent {
+	c.pos++
+	for i, n := c.pos, len(c.json); i < n; i++ {
+		switch ch := c.json[i]; {
+		case ch == '"':
+			c.pos = i + 1
+			return LeptParseOK
+		case ch == '\\':
+			if i+1 >= n {
+				return LeptParseInvalidStringEscape
+			}
+			switch c.json[i+1] {
+			case '"', '\\', 'b', 'f', 'n', 'r', 't', '/':
+			case 'u':
+				rr := getu4(c.json[i+2:])
+				if rr < 0 {
+					return LeptParseInvalidUnicodeHex
+				}
+				if utf16.IsSurrogate(rr) {
+					if rr >= 0xDC00 || i+7 >= n || c.json[i+6] != '\\' || c.json[i+7] != 'u' {
+						return LeptParseInvalidUnicodeSurrogate
+					}
+					if rr1 := getu4(c.json[i+8:]); rr1 < 0xDC00 || rr1 > 0xDFFF {
+						return LeptParseInvalidUnicodeSurrogate
+					}
+					i += 6
+				}
+				i += 4
+			default:
+				return LeptParseInvalidStringEscape
+			}
+			i++
+		case ch < 0x20:
+			return LeptParseInvalidStringChar
+		}
+	}
+	return LeptParseMissQuotationMark
+}
+
+// leptSkipArray validate an array like LeptParseArray
+func leptSkipArray(c *LeptContext, h LeptEventHandler) LeptEvent {
+	c.pos++
+	if h != nil {
+		h.OnArrayStart()
+	}
+	LeptParseWhitespace(c)
+	if c.peek() == ']' {
+		c.pos++
+		if h != nil {
+			h.OnArrayEnd()
+		}
+		return LeptParseOK
+	}
+	if c.eof() {
+		return LeptParseMissCommaOrSouareBracket
+	}
+	for {
+		if ret := leptSkipValue(c, h); ret != LeptParseOK {
+			return ret
+		}
+		LeptParseWhitespace(c)
+		switch c.peek() {
+		case ',':
+			c.pos++
+			LeptParseWhitespace(c)
+		case ']':
+			c.pos++
+			if h != nil {
+				h.OnArrayEnd()
+			}
+			return LeptParseOK
+		default:
+			return LeptParseMissCommaOrSouareBracket
+		}
+	}
+}
+
+// leptSkipObject validate an object like LeptParseObject
+func leptSkipObject(c *LeptContext, h LeptEventHandler) LeptEvent {
+	c.pos++
+	if h != nil {
+		h.OnObjectStart()
+	}
+	LeptParseWhitespace(c)
+	if c.peek() == '}' {
+		c.pos++
+		if h != nil {
+			h.OnObjectEnd()
+		}
+		return LeptParseOK
+	}
+	if c.eof() {
+		return LeptParseMissCommaOrCurlyBracket
+	}
+	for {
+		if c.peek() != '"' {
+			return LeptParseMissKey
+		}
+		if h == nil {
+			if ret := leptSkipString(c); ret != LeptParseOK {
+				return ret
+			}
+		} else {
+			key, ret := LeptParseStringRaw(c)
+			if ret != LeptParseOK {
+				return ret
+			}
+			h.OnKey(key)
+		}
+		LeptParseWhitespace(c)
+		if c.peek() != ':' {
+			return LeptParseMissColon
+		}
+		c.pos++
+		LeptParseWhitespace(c)
+		if ret := leptSkipValue(c, h); ret != LeptParseOK {
+			return ret
+		}
+		LeptParseWhitespace(c)
+		switch c.peek() {
+		case ',':
+			c.pos++
+			LeptParseWhitespace(c)
+		case '}':
+			c.pos++
+			if h != nil {
+				h.OnObjectEnd()
+			}
+			return LeptParseOK
+		default:
+			return LeptParseMissCommaOrCurlyBracket
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package goleptjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLeptValidatingWriter(t *testing.T) {
+	input := "null true\nfalse 123 -1.5e3 \"a b\\\"c\\u00e9\\uD834\\uDD1E\"\n[1, [2], {}]{\"a\":{\"b\":\"}\"}, \"c\":[]}\r\n  0.5 "
+	for _, size := range []int{1, 2, 3, 7, len(input)} {
+		var buf bytes.Buffer
+		w := NewLeptValidatingWriter(&buf)
+		for i := 0; i < len(input); i += size {
+			end := i + size
+			if end > len(input) {
+				end = len(input)
+			}
+			if n, err := w.Write([]byte(input[i:end])); err != nil || n != end-i {
+				t.Errorf("LeptValidatingWriter Write chunk %v expect no err: %v", size, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Errorf("LeptValidatingWriter Close expect no err: %v", err)
+		}
+		expectEQString(t, input, buf.String())
+	}
+}
+
+func TestLeptValidatingWriterInvalid(t *testing.T) {
+	// stop at the invalid byte across chunks
+	var buf bytes.Buffer
+	w := NewLeptValidatingWriter(&buf)
+	if _, err := w.Write([]byte("[1, 2] {\"a\"")); err != nil {
+		t.Errorf("LeptValidatingWriter Write expect no err: %v", err)
+	}
+	n, err := w.Write([]byte(" 3}"))
+	expectEQInt(t, 1, n)
+	e, ok := err.(*LeptError)
+	if !ok {
+		t.Errorf("LeptValidatingWriter Write expect *LeptError, actual: %v", err)
+		return
+	}
+	expectEQLeptEvent(t, LeptParseMissColon, e.Event)
+	expectEQInt(t, 12, e.Offset)
+	expectEQString(t, "[1, 2] {\"a\" ", buf.String())
+	if _, err := w.Write([]byte("}")); err != e {
+		t.Errorf("LeptValidatingWriter expect the sticky err, actual: %v", err)
+	}
+	if err := w.Close(); err != e {
+		t.Errorf("LeptValidatingWriter Close expect the sticky err, actual: %v", err)
+	}
+
+	// the same events as LeptParse for a single value
+	invalid := []string{
+		"[", "[1", "[1,", "[1 2]", "[1,]",
+		"{", "{1", "{\"a\"", "{\"a\" 1}", "{\"a\":", "{\"a\":1", "{\"a\":1,", "{\"a\":1 \"b\":2}", "{\"a\":1,}",
+		"-", "+1", ".5", "1.", "1e", "1e+", "0123", "0x0", "1e309", "nul", "tru", "fals", "?",
+		"\"", "\"abc", "\"\\", "\"\\v\"", "\"\\u12\"", "\"\\u12", "\"\\uD800\"", "\"\\uD800", "\"\\uD800\\",
+		"\"\\uD800\\u", "\"\\uD800\\uE000\"", "\"\\uDC00\"", "\"\x01\"", "[\"a\", {\"b\":[nul]}]",
+		"1true", "nulltrue", "1[2]", "false\"a\"", "-0x", "[0x1]", "[1] 2{}",
+	}
+	for _, input := range invalid {
+		expect := LeptParse(NewLeptValue(), input)
+		w := NewLeptValidatingWriter(&buf)
+		_, err := w.Write([]byte(input))
+		if err == nil {
+			err = w.Close()
+		}
+		e, ok := err.(*LeptError)
+		if !ok {
+			t.Errorf("LeptValidatingWriter %q expect *LeptError, actual: %v", input, err)
+			continue
+		}
+		if e.Event != expect {
+			t.Errorf("LeptValidatingWriter %q expect: %v, actual: %v", input, expect, e.Event)
+		}
+	}
+}
+
+func TestLeptValidatingWriterDelimiter(t *testing.T) {
+	// a top level number or literal needs whitespace after it, even across chunks
+	var buf bytes.Buffer
+	w := NewLeptValidatingWriter(&buf)
+	w.Write([]byte("[1]\"a\"{} 12"))
+	n, err := w.Write([]byte("true"))
+	expectEQInt(t, 0, n)
+	if e, ok := err.(*LeptError); !ok || e.Event != LeptParseRootNotSingular || e.Offset != 11 {
+		t.Errorf("LeptValidatingWriter expect root not singular at offset 11, actual: %v", err)
+	}
+	expectEQString(t, "[1]\"a\"{} 12", buf.String())
+
+	// the number is buffered up to MaxNumberBytes
+	long := "0." + strings.Repeat("1", LeptDefaultMaxNumberBytes)
+	w = NewLeptValidatingWriter(&strings.Builder{})
+	_, err = w.Write([]byte(long))
+	if e, ok := err.(*LeptError); !ok || e.Event != LeptParseNumberTooBig || e.Offset != LeptDefaultMaxNumberBytes {
+		t.Errorf("LeptValidatingWriter expect number too big, actual: %v", err)
+	}
+	w = NewLeptValidatingWriter(&strings.Builder{})
+	w.MaxNumberBytes = 0
+	if _, err := w.Write([]byte(long + " ")); err != nil {
+		t.Errorf("LeptValidatingWriter unlimited number expect no err: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("LeptValidatingWriter unlimited number expect no err: %v", err)
+	}
+}
+
+func TestLeptValidatingWriterEmpty(t *testing.T) {
+	w := NewLeptValidatingWriter(&strings.Builder{})
+	if err := w.Close(); err != nil {
+		t.Errorf("LeptValidatingWriter empty stream expect no err: %v", err)
+	}
+	w = NewLeptValidatingWriter(&strings.Builder{})
+	w.Write([]byte(" \n\t "))
+	if err := w.Close(); err != nil {
+		t.Errorf("LeptValidatingWriter whitespace stream expect no err: %v", err)
+	}
+}
+
+func TestLeptValidate(t *testing.T) {
+	inputs := []string{
+		"null", " true ", "false", "0", "-1.5e3", "1e-400", "\"\"", "\"a\\u00e9\\n\\/\"", "\"\\uD834\\uDD1E\"",
+		"[]", "{}", "[ ]", "{ }", "[1,[],{},[[]],{\"a\":{}}]",
+		"{\"z\":1,\"a\":[true,false,null],\"m\":{\"x\":\"y\",\"\":[{}]}}",
+		" [ 1 , [ 2 , [ 3 ] ] , { \"k\" : [ ] } ] ",
+		"{\"a\":1,\"a\":2}",
+		"", " ", "nul", "tru", "fals", "?", "+1", "01", "1.", "1e", "1e309", "-1e309", "1e10001",
+		"\"", "\"abc", "\"\\", "\"\\v\"", "\"\\u12\"", "\"\\u12g4\"", "\"\x01\"",
+		"\"\\uD800\"", "\"\\uDC00\"", "\"\\uD800\\u0041\"", "\"\\uD800\\\\\"", "\"\\uD834\\uDD1",
+		"[", "{", "[1", "[1,", "[1,]", "[1 2]", "[1}", "{\"a\":1]",
+		"{\"a\"", "{\"a\":", "{\"a\":1,", "{\"a\":1,}", "{1:1}", "{\"a\" 1}", "{\"a\\x\":1}",
+		"[nul]", "[[[1,tru]]]", "{\"a\":[{\"b\":?}]}", "[1]x", "[1] [2]", "null x",
+		strings.Repeat("[", 1000) + strings.Repeat("]", 1000),
+		strings.Repeat("[", 1001) + strings.Repeat("]", 1001),
+		strings.Repeat("{\"a\":", 1001),
+	}
+	for _, input := range inputs {
+		ce := NewLeptContext(input)
+		expect := leptParse(ce, NewLeptValue())
+		ca := NewLeptContext(input)
+		expectEQLeptEvent(t, expect, leptValidate(ca, nil))
+		expectEQLeptEvent(t, expect, LeptValidate(input))
+		if ce.pos != ca.pos {
+			t.Errorf("LeptValidate %q expect offset %d, actual: %d", input, ce.pos, ca.pos)
+		}
+	}
+	// nothing is allocated for the valid document
+	input := "{\"data\":[" + benchmarkRecord(1) + "," + benchmarkRecord(2) + "],\"total\":2}"
+	allocs := testing.AllocsPerRun(100, func() {
+		if event := LeptValidate(input); event != LeptParseOK {
+			t.Errorf("LeptValidate %q: %v", input, event)
+		}
+	})
+	expectEQFloat64(t, 0, allocs)
+}
+
+func benchmarkLargeObject() string {
+	items := make([]string, 20000)
+	for i := range items {
+		items[i] = benchmarkRecord(i)
+	}
+	return "{\"data\":[\n" + strings.Join(items, ",\n") + "\n],\"total\":20000}"
+}
+func BenchmarkValidateLarge(b *testing.B) {
+	input := benchmarkLargeObject()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if event := LeptValidate(input); event != LeptParseOK {
+			b.Errorf("benchmark validate err : %v", event)
+		}
+	}
+}
+func BenchmarkValidateLargeByParse(b *testing.B) {
+	input := benchmarkLargeObject()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if event := LeptParse(NewLeptValue(), input); event != LeptParseOK {
+			b.Errorf("benchmark parse err : %v", event)
+		}
+	}
+}
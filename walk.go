@@ -0,0 +1,152 @@
+package goleptjson
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// leptPointerEscaper escape the reference token of JSON Pointer (RFC 6901)
+var leptPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// leptWalk visit v and all its descendants depth first, array elements and object members in order,
+// path is the JSON Pointer of v, "" means the root
+func leptWalk(v *LeptValue, path string, fn func(path string, v *LeptValue)) {
+	fn(path, v)
+	switch v.typ {
+	case LeptArray:
+		for i, e := range v.a {
+			leptWalk(e, path+"/"+strconv.Itoa(i), fn)
+		}
+	case LeptObject:
+		for _, m := range v.o {
+			leptWalk(m.value, path+"/"+leptPointerEscaper.Replace(m.key), fn)
+		}
+	}
+}
+
+// LeptWalk visit v and all its descendants depth first with fn, a container before its children,
+// array elements and object members in order, path is the JSON Pointer of the node, "" means the root
+func LeptWalk(v *LeptValue, fn func(path string, v *LeptValue)) {
+	if v == nil {
+		panic("LeptWalk v is nil")
+	}
+	leptWalk(v, "", fn)
+}
+
+// LeptCollectStrings return all the string leaves satisfying pred in walk order,
+// pred get the JSON Pointer and the content of string
+func LeptCollectStrings(v *LeptValue, pred func(path, s string) bool) []string {
+	if v == nil {
+		panic("LeptCollectStrings v is nil")
+	}
+	var ret []string
+	leptWalk(v, "", func(path string, e *LeptValue) {
+		if e.typ == LeptString && pred(path, e.s) {
+			ret = append(ret, e.s)
+		}
+	})
+	return ret
+}
+
+// LeptCount return the number of nodes satisfying pred in the walk of v, v itself included
+func LeptCount(v *LeptValue, pred func(path string, node *LeptValue) bool) int {
+	if v == nil {
+		panic("LeptCount v is nil")
+	}
+	count := 0
+	leptWalk(v, "", func(path string, e *LeptValue) {
+		if pred(path, e) {
+			count++
+		}
+	})
+	return count
+}
+
+// LeptMapNumbers replace every number of v with fn of it in place, the other values are untouched,
+// the interned numbers are replaced by new values instead of mutated
+func LeptMapNumbers(v *LeptValue, fn func(float64) float64) {
+	if v == nil {
+		panic("LeptMapNumbers v is nil")
+	}
+	leptCheckMutable(v, "LeptMapNumbers")
+	switch v.typ {
+	case LeptNumber:
+		v.n = fn(v.n)
+		v.text = ""
+	case LeptArray:
+		for i, e := range v.a {
+			v.a[i] = leptMapNumber(e, fn)
+		}
+	case LeptObject:
+		for _, member := range v.o {
+			member.value = leptMapNumber(member.value, fn)
+		}
+	}
+}
+
+func leptMapNumber(v *LeptValue, fn func(float64) float64) *LeptValue {
+	if v.shared {
+		e := NewLeptValue()
+		LeptSetNumber(e, fn(v.n))
+		return e
+	}
+	LeptMapNumbers(v, fn)
+	return v
+}
+
+// LeptRoundNumbers round every number of v to decimals places in place, negative decimals round to tens, hundreds and so on.
+// the rounding mode is half away from zero on the shortest decimal form of the number,
+// so 0.125 -> 0.13, -0.125 -> -0.13 and 1.005 -> 1.01 though 1.005 is a bit less than it in binary,
+// the number rounded to zero is 0 instead of -0
+func LeptRoundNumbers(v *LeptValue, decimals int) {
+	LeptMapNumbers(v, func(n float64) float64 {
+		return leptRoundHalfAwayFromZero(n, decimals)
+	})
+}
+
+// leptMaxRoundDecimals bound the decimals of rounding, the shortest form of a float64 has less than 350 decimal places
+// and is less than 1e309, so a larger scale only costs time and memory
+const leptMaxRoundDecimals = 350
+
+func leptRoundHalfAwayFromZero(n float64, decimals int) float64 {
+	if decimals > leptMaxRoundDecimals {
+		return n
+	}
+	if decimals < -leptMaxRoundDecimals {
+		return 0
+	}
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(n, 'g', -1, 64))
+	if !ok {
+		return n
+	}
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(leptAbs(decimals))), nil))
+	if decimals >= 0 {
+		r.Mul(r, scale)
+	} else {
+		r.Quo(r, scale)
+	}
+	// q = (2 * |num| + den) / (2 * den)
+	num := new(big.Int).Abs(r.Num())
+	den := new(big.Int).Mul(r.Denom(), big.NewInt(2))
+	q := num.Add(num.Lsh(num, 1), r.Denom())
+	q.Quo(q, den)
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	r.SetInt(q)
+	if decimals >= 0 {
+		r.Quo(r, scale)
+	} else {
+		r.Mul(r, scale)
+	}
+	f, _ := r.Float64()
+	return f
+}
+
+func leptAbs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,158 @@
+package goleptjson
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLeptWalk(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"a\":[1,{\"b\":null}],\"c/d\":{\"~e\":\"s\",\"\":[]},\"f\":true}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	var paths, types []string
+	LeptWalk(v, func(path string, node *LeptValue) {
+		paths = append(paths, path)
+		types = append(types, LeptGetType(node).String())
+	})
+	expect := []string{"", "/a", "/a/0", "/a/1", "/a/1/b", "/c~1d", "/c~1d/~0e", "/c~1d/", "/f"}
+	if !reflect.DeepEqual(expect, paths) {
+		t.Errorf("LeptWalk paths expect: %v, actual: %v", expect, paths)
+	}
+	expect = []string{"LeptObject", "LeptArray", "LeptNumber", "LeptObject", "LeptNull", "LeptObject", "LeptString", "LeptArray", "LeptTrue"}
+	if !reflect.DeepEqual(expect, types) {
+		t.Errorf("LeptWalk types expect: %v, actual: %v", expect, types)
+	}
+	// every path resolves to the visited node
+	LeptWalk(v, func(path string, node *LeptValue) {
+		if found, err := LeptResolvePointer(v, path); err != nil || found != node {
+			t.Errorf("LeptWalk path %q resolves to %v, err: %v", path, found, err)
+		}
+	})
+	// redact in place
+	LeptWalk(v, func(path string, node *LeptValue) {
+		if node.typ == LeptString {
+			LeptSetString(node, "***")
+		}
+	})
+	expectEQString(t, "{\"a\":[1,{\"b\":null}],\"c/d\":{\"~e\":\"***\",\"\":[]},\"f\":true}", LeptStringify(v))
+	expectPanic(t, "LeptWalk", func() { LeptWalk(nil, func(string, *LeptValue) {}) })
+}
+
+func TestLeptCollectStrings(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"name\":\"a\",\"links\":{\"home\":\"http://a.com\",\"list\":[\"http://b.com\",1,\"ftp://c.com\",{\"x\":\"http://d.com\"}]},\"a/b\":{\"~\":\"http://e.com\"}}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+
+	all := LeptCollectStrings(v, func(path, s string) bool { return true })
+	expect := []string{"a", "http://a.com", "http://b.com", "ftp://c.com", "http://d.com", "http://e.com"}
+	if !reflect.DeepEqual(expect, all) {
+		t.Errorf("LeptCollectStrings expect: %v, actual: %v", expect, all)
+	}
+
+	// the http urls under /links/list
+	urls := LeptCollectStrings(v, func(path, s string) bool {
+		return strings.HasPrefix(path, "/links/list/") && strings.HasPrefix(s, "http://")
+	})
+	expect = []string{"http://b.com", "http://d.com"}
+	if !reflect.DeepEqual(expect, urls) {
+		t.Errorf("LeptCollectStrings expect: %v, actual: %v", expect, urls)
+	}
+
+	var paths []string
+	LeptCollectStrings(v, func(path, s string) bool {
+		paths = append(paths, path)
+		return false
+	})
+	expect = []string{"/name", "/links/home", "/links/list/0", "/links/list/2", "/links/list/3/x", "/a~1b/~0"}
+	if !reflect.DeepEqual(expect, paths) {
+		t.Errorf("LeptCollectStrings paths expect: %v, actual: %v", expect, paths)
+	}
+
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "\"root\""))
+	paths = paths[:0]
+	expectEQInt(t, 1, len(LeptCollectStrings(v, func(path, s string) bool {
+		paths = append(paths, path)
+		return true
+	})))
+	expectEQString(t, "", paths[0])
+}
+
+func TestLeptCount(t *testing.T) {
+	v := NewLeptValue()
+	input := "{\"items\":[{\"id\":1,\"deleted\":true},{\"id\":2},{\"id\":3,\"deleted\":false,\"sub\":{\"deleted\":null}}],\"deleted\":[]}"
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, input))
+	// the objects having deleted
+	expectEQInt(t, 4, LeptCount(v, func(path string, node *LeptValue) bool {
+		return node.typ == LeptObject && LeptFindObjectIndex(node, "deleted") != LeptKeyNotExist
+	}))
+	// only under items
+	expectEQInt(t, 3, LeptCount(v, func(path string, node *LeptValue) bool {
+		return strings.HasPrefix(path, "/items/") && node.typ == LeptObject && LeptFindObjectIndex(node, "deleted") != LeptKeyNotExist
+	}))
+	// all nodes
+	expectEQInt(t, 13, LeptCount(v, func(path string, node *LeptValue) bool { return true }))
+	expectEQInt(t, 0, LeptCount(v, func(path string, node *LeptValue) bool { return false }))
+}
+
+func TestLeptMapNumbers(t *testing.T) {
+	round := func(n float64) float64 {
+		return math.Round(n*100) / 100
+	}
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{\"price\":1.23456,\"items\":[0.005,2,{\"w\":-3.14159,\"s\":\"1.23456\"}],\"ok\":true,\"n\":null}"))
+	LeptMapNumbers(v, round)
+	expectEQString(t, "{\"price\":1.23,\"items\":[0.01,2,{\"w\":-3.14,\"s\":\"1.23456\"}],\"ok\":true,\"n\":null}", LeptStringifyWithOptions(v, nil))
+
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "2.5"))
+	LeptMapNumbers(v, func(n float64) float64 { return n * 2 })
+	expectEQFloat64(t, 5, LeptGetNumber(v))
+
+	// the interned numbers are not mutated
+	opts := NewLeptParseOptions()
+	opts.InternSmallIntegers = true
+	v = NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(v, "[1, {\"a\":2}]", opts))
+	LeptMapNumbers(v, func(n float64) float64 { return n * 10 })
+	expectEQString(t, "[10,{\"a\":20}]", LeptStringify(v))
+	other := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParseWithOptions(other, "[1, 2]", opts))
+	expectEQString(t, "[1,2]", LeptStringify(other))
+}
+
+func TestLeptRoundNumbers(t *testing.T) {
+	valid := []struct {
+		n        float64
+		decimals int
+		expect   float64
+	}{
+		{0.125, 2, 0.13},
+		{-0.125, 2, -0.13},
+		{0.124, 2, 0.12},
+		{1.005, 2, 1.01},
+		{2.675, 2, 2.68},
+		{0.5, 0, 1},
+		{-0.5, 0, -1},
+		{1.4999, 0, 1},
+		{123.456, 1, 123.5},
+		{1250, -2, 1300},
+		{-1249, -2, -1200},
+		{0.1 + 0.2, 10, 0.3},
+		{1e300, 2, 1e300},
+		{5e-324, 2, 0},
+		{7, 3, 7},
+		{5e-324, 350, 5e-324},
+		{0.125, 1 << 30, 0.125},
+		{-1.7976931348623157e308, -309, 0},
+		{1.7976931348623157e308, -1 << 30, 0},
+	}
+	for _, c := range valid {
+		expectEQFloat64(t, c.expect, leptRoundHalfAwayFromZero(c.n, c.decimals))
+	}
+	v := NewLeptValue()
+	expectEQLeptEvent(t, LeptParseOK, LeptParse(v, "{\"a\":0.125,\"b\":[-0.125,0.30000000000000004,\"0.125\"],\"c\":-0.001}"))
+	LeptRoundNumbers(v, 2)
+	expectEQString(t, "{\"a\":0.13,\"b\":[-0.13,0.3,\"0.125\"],\"c\":0}", LeptStringifyWithOptions(v, nil))
+}
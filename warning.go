@@ -0,0 +1,86 @@
+package goleptjson
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LeptWarningKind enums of parse warning
+type LeptWarningKind int
+
+const (
+	// LeptWarningPrecisionLoss number has more significant digits than float64 can hold
+	LeptWarningPrecisionLoss LeptWarningKind = iota
+	// LeptWarningDuplicateKey object has the key more than once
+	LeptWarningDuplicateKey
+)
+
+var warningKindNames = []string{
+	"LeptWarningPrecisionLoss",
+	"LeptWarningDuplicateKey",
+}
+
+func (kind LeptWarningKind) String() string {
+	if int(kind) < len(warningKindNames) {
+		return warningKindNames[kind]
+	}
+	return "LeptWarningUnknown"
+}
+
+// LeptWarning a non-fatal observation of parsing
+type LeptWarning struct {
+	Kind LeptWarningKind
+	// Offset the byte offset of the number or key
+	Offset int
+	// Text the number literal or key
+	Text string
+}
+
+func (w LeptWarning) String() string {
+	return fmt.Sprintf("%v %q at offset %v", w.Kind, w.Text, w.Offset)
+}
+
+type leptWarnings []LeptWarning
+
+func (ws *leptWarnings) add(kind LeptWarningKind, offset int, text string) {
+	*ws = append(*ws, LeptWarning{Kind: kind, Offset: offset, Text: text})
+}
+
+// LeptParseWithWarnings use to parse value with the options and report the warnings,
+// the warnings never fail the parsing
+func LeptParseWithWarnings(v *LeptValue, json string, opts *LeptParseOptions) (LeptEvent, []LeptWarning) {
+	if v == nil {
+		panic("LeptParseWithWarnings v is nil")
+	}
+	c := NewLeptContext(json)
+	if opts != nil {
+		c.opts = opts
+	}
+	c.warnings = &leptWarnings{}
+	event := leptParse(c, v)
+	return event, *c.warnings
+}
+
+// leptWarn add a warning of text which starts at the current position
+func leptWarn(c *LeptContext, kind LeptWarningKind, text string) {
+	c.warnings.add(kind, leptOffset(c), text)
+}
+
+// leptLosesPrecision compare the significant digits of literal and the shortest form of n
+func leptLosesPrecision(literal string, n float64) bool {
+	return leptSignificantDigits(literal) != leptSignificantDigits(strconv.FormatFloat(n, 'e', -1, 64))
+}
+
+// leptSignificantDigits return the digits of mantissa without leading and trailing zeros
+func leptSignificantDigits(number string) string {
+	digits := make([]byte, 0, len(number))
+	for i := 0; i < len(number) && number[i] != 'e' && number[i] != 'E'; i++ {
+		if isDigit(number[i]) && (len(digits) > 0 || number[i] != '0') {
+			digits = append(digits, number[i])
+		}
+	}
+	for len(digits) > 0 && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+	}
+	return string(digits)
+}
@@ -0,0 +1,54 @@
+package goleptjson
+
+import (
+	"testing"
+)
+
+func TestLeptParseWithWarnings(t *testing.T) {
+	valid := []struct {
+		input  string
+		expect []LeptWarning
+	}{
+		{"[0, 0.1, 1.5e300, 9007199254740992, -1.00]", nil},
+		{"123456789012345678", []LeptWarning{
+			{LeptWarningPrecisionLoss, 0, "123456789012345678"},
+		}},
+		{"[1, 12345678901234567890]", []LeptWarning{
+			{LeptWarningPrecisionLoss, 4, "12345678901234567890"},
+		}},
+		{"1.00000000000000000001", []LeptWarning{
+			{LeptWarningPrecisionLoss, 0, "1.00000000000000000001"},
+		}},
+		{"{\"a\":1e-400}", []LeptWarning{
+			{LeptWarningPrecisionLoss, 5, "1e-400"},
+		}},
+		{"{\"a\":1,\"b\":2,\"a\":3}", []LeptWarning{
+			{LeptWarningDuplicateKey, 13, "a"},
+		}},
+		{"{\"a\":1,\"a\":2,\"b\":3,\"a\":4}", []LeptWarning{
+			{LeptWarningDuplicateKey, 7, "a"},
+			{LeptWarningDuplicateKey, 19, "a"},
+		}},
+		{"{\"a\":{\"a\":1},\"b\":[{\"a\":1}]}", nil},
+		{"{\"k\":1,\"k\":9007199254740993}", []LeptWarning{
+			{LeptWarningDuplicateKey, 7, "k"},
+			{LeptWarningPrecisionLoss, 11, "9007199254740993"},
+		}},
+	}
+	for _, c := range valid {
+		v := NewLeptValue()
+		event, warnings := LeptParseWithWarnings(v, c.input, nil)
+		expectEQLeptEvent(t, LeptParseOK, event)
+		expectEQInt(t, len(c.expect), len(warnings))
+		for i := 0; i < len(c.expect) && i < len(warnings); i++ {
+			if c.expect[i] != warnings[i] {
+				t.Errorf("LeptParseWithWarnings %v, expect: %v, actual: %v", c.input, c.expect[i], warnings[i])
+			}
+		}
+	}
+	// warnings never fail the parsing
+	v := NewLeptValue()
+	event, warnings := LeptParseWithWarnings(v, "{\"a\":1,\"a\":2", nil)
+	expectEQLeptEvent(t, LeptParseMissCommaOrCurlyBracket, event)
+	expectEQInt(t, 1, len(warnings))
+}